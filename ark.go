@@ -0,0 +1,220 @@
+// ark.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ARKs are described by the ARK specification, <https://datatracker.ietf.org/doc/draft-kunze-ark/>,
+// and minted following the conventions of NOID, <https://metacpan.org/dist/Noid/view/noid>.
+
+const (
+	// ARKPattern matches an ARK in its "ark:NAAN/Name" or older "ark:/NAAN/Name" form with an optional resolver URL.
+	ARKPattern = `(https?://\S+/)?[aA][rR][kK]:\/?[0-9bcdfghjkmnpqrstvwxz]{5,}\/[0-9A-Za-z=~*+@_$.\/%\-]+`
+
+	// betanumeric holds the digits and consonants (except "l" and "y") NOID uses
+	// for blades, shoulders and check characters.
+	betanumeric = "0123456789bcdfghjkmnpqrstvwxz"
+
+	// DefaultARKBladeLength is the number of random characters in a minted ARK's name
+	// between the shoulder and the check character.
+	DefaultARKBladeLength = 8
+)
+
+var (
+	// ReNAAN matches a Name Assigning Authority Number, five or more betanumeric characters.
+	ReNAAN *regexp.Regexp
+
+	// ReARKName matches the characters allowed in an ARK's name and qualifiers.
+	ReARKName *regexp.Regexp
+)
+
+// parseARK splits an ARK into its NAAN and its name (including any qualifiers). It
+// accepts the "ark:NAAN/Name" and "ark:/NAAN/Name" forms with or without a resolver
+// URL, e.g. "https://n2t.net/ark:/13030/tf5p30086k".
+func parseARK(formValue string) (string, string, error) {
+	val := strings.TrimSpace(formValue)
+	lower := strings.ToLower(val)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		i := strings.Index(lower, "/ark:")
+		if i < 0 {
+			return "", "", fmt.Errorf("%q is not an ARK URL", formValue)
+		}
+		val, lower = val[i+1:], lower[i+1:]
+	}
+	if !strings.HasPrefix(lower, "ark:") {
+		return "", "", fmt.Errorf("%q is missing the \"ark:\" label", formValue)
+	}
+	val = strings.TrimPrefix(val[4:], "/")
+	naan, name, ok := strings.Cut(val, "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("%q is missing a name", formValue)
+	}
+	naan = strings.ToLower(naan)
+	if !ReNAAN.MatchString(naan) {
+		return "", "", fmt.Errorf("%q is not a NAAN", naan)
+	}
+	if !ReARKName.MatchString(name) {
+		return "", "", fmt.Errorf("%q holds characters not allowed in an ARK", name)
+	}
+	return naan, name, nil
+}
+
+// normalizeARKName removes hyphens, uppercases the hex digits of percent encoded
+// characters and drops trailing structural characters ("/" and ".") from an ARK's
+// name and qualifiers. Hyphens are identity inert in ARKs.
+func normalizeARKName(name string) string {
+	name = strings.ReplaceAll(name, "-", "")
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		sb.WriteByte(name[i])
+		if name[i] == '%' && i+2 < len(name) {
+			sb.WriteString(strings.ToUpper(name[i+1 : i+3]))
+			i += 2
+		}
+	}
+	return strings.TrimRight(sb.String(), "/.")
+}
+
+// noidCheckChar calculates the NOID check character of an identifier, e.g. "13030/xf93gt2"
+// has the check character "q". Each betanumeric character's value is multiplied by its
+// position, other characters count as zero.
+func noidCheckChar(id string) byte {
+	total := 0
+	for i := 0; i < len(id); i++ {
+		if d := strings.IndexByte(betanumeric, id[i]); d > 0 {
+			total += (i + 1) * d
+		}
+	}
+	return betanumeric[total%len(betanumeric)]
+}
+
+// MintARK mints a new ARK from a NAAN and shoulder, e.g. MintARK("12345", "x6", 8) returns
+// something like "ark:12345/x6np1wh8kq". The name is the shoulder followed by a random
+// blade of betanumeric characters and a NOID check character. If bladeLength isn't
+// positive DefaultARKBladeLength is used.
+func MintARK(naan string, shoulder string, bladeLength int) (string, error) {
+	naan = strings.ToLower(strings.TrimSpace(naan))
+	if !ReNAAN.MatchString(naan) {
+		return "", fmt.Errorf("%q is not a NAAN", naan)
+	}
+	shoulder = strings.TrimSpace(shoulder)
+	for i := 0; i < len(shoulder); i++ {
+		if strings.IndexByte(betanumeric, shoulder[i]) < 0 {
+			return "", fmt.Errorf("shoulder %q must be made of betanumeric characters (%s)", shoulder, betanumeric)
+		}
+	}
+	if bladeLength <= 0 {
+		bladeLength = DefaultARKBladeLength
+	}
+	blade := make([]byte, bladeLength)
+	max := big.NewInt(int64(len(betanumeric)))
+	for i := range blade {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		blade[i] = betanumeric[n.Int64()]
+	}
+	id := naan + "/" + shoulder + string(blade)
+	return "ark:" + id + string(noidCheckChar(id)), nil
+}
+
+// MintElementARK mints an ARK using the element's "naan", "shoulder" and optional
+// "blade_length" attributes. It is the value generator for elements with the
+// generator "ark".
+func MintElementARK(elem *Element) (string, error) {
+	naan, ok := elem.Attributes["naan"]
+	if !ok {
+		return "", fmt.Errorf("element %q is missing the naan attribute needed to mint an ARK", elem.Id)
+	}
+	bladeLength := 0
+	if val, ok := elem.Attributes["blade_length"]; ok {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return "", fmt.Errorf("element %q has an invalid blade_length %q", elem.Id, val)
+		}
+		bladeLength = n
+	}
+	return MintARK(naan, elem.Attributes["shoulder"], bladeLength)
+}
+
+// GenerateARK sets up an HTML input type text using a pattern for ARK
+func GenerateARK() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     ARKPattern,
+			"placeholder": "e.g. ark:12345/x6np1wh8k",
+		},
+	}
+}
+
+// ValidateARK checks an ARK's label, NAAN and the characters of its name. If the element
+// mints ARKs (its generator is "ark") then ARKs with the element's NAAN and shoulder must
+// end in a valid NOID check character.
+func ValidateARK(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	naan, name, err := parseARK(formValue)
+	if err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	if elem.Generator == "ark" && naan == strings.ToLower(elem.Attributes["naan"]) && strings.HasPrefix(name, elem.Attributes["shoulder"]) {
+		// NOTE: the check character covers the base name, qualifiers follow a "/" or "."
+		base := normalizeARKName(name)
+		if i := strings.IndexAny(base, "/."); i >= 0 {
+			base = base[0:i]
+		}
+		if len(base) < 2 || noidCheckChar(naan+"/"+base[0:len(base)-1]) != base[len(base)-1] {
+			if Debug {
+				log.Printf("DEBUG failed to validate check character elem.Id %q, elem.Type %q, value %q\n", elem.Id, elem.Type, formValue)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	ReNAAN = regexp.MustCompile(`^[0-9bcdfghjkmnpqrstvwxz]{5,}$`)
+	ReARKName = regexp.MustCompile(`^([0-9A-Za-z=~*+@_$./-]|%[0-9A-Fa-f]{2})+$`)
+}
@@ -0,0 +1,281 @@
+// bind.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	// 3rd Party packages
+	"github.com/google/uuid"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	uuidType     = reflect.TypeOf(uuid.UUID{})
+	geoPointType = reflect.TypeOf(GeoPoint{})
+	geoBBoxType  = reflect.TypeOf(GeoBBox{})
+)
+
+// boundFields returns a map of element id to struct field for the fields
+// tagged with `model:"id"` in the struct pointed to by target.
+func boundFields(target interface{}) (reflect.Value, map[string]reflect.StructField, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return rv, nil, fmt.Errorf("expected a pointer to a struct, got %T", target)
+	}
+	rv = rv.Elem()
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		tag, ok := field.Tag.Lookup("model")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		id := strings.TrimSpace(strings.Split(tag, ",")[0])
+		if id == "" || id == "-" {
+			continue
+		}
+		fields[id] = field
+	}
+	return rv, fields, nil
+}
+
+// isCompatible checks if a struct field can hold the decoded value of an element.
+// String (and interface) fields can hold any element's form value.
+func (model *Model) isCompatible(elem *Element, t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String || t.Kind() == reflect.Interface {
+		return true
+	}
+	// NOTE: custom decoders without a Go type can return any type so they are checked when bound
+	if def, ok := model.GetTypeDefinition(elem.Type); ok && def.Decode != nil && def.GoType == "" {
+		return true
+	}
+	switch model.resolveType(elem.Type).GoType {
+	case "time.Time":
+		return t == timeType
+	case "uuid.UUID":
+		return t == uuidType
+	case "models.GeoPoint":
+		return t == geoPointType
+	case "models.GeoBBox":
+		return t == geoBBoxType
+	case "bool":
+		return t.Kind() == reflect.Bool
+	case "float64", "int64":
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// CheckStruct compares the fields of the struct pointed to by target with the
+// model's elements. Fields are matched to element ids with a `model:"id"` tag.
+// It returns an error listing tagged fields that don't match an element, elements
+// that don't have a tagged field and fields whose type can't hold the element's value.
+func (model *Model) CheckStruct(target interface{}) error {
+	_, fields, err := boundFields(target)
+	if err != nil {
+		return err
+	}
+	result := new(ValidationResult)
+	for _, elem := range model.Elements {
+		if elem.Id == "" {
+			continue
+		}
+		field, ok := fields[elem.Id]
+		if !ok {
+			result.Add(elem, "", CodeUnboundElement, "no struct field is tagged for element")
+			continue
+		}
+		if !model.isCompatible(elem, field.Type) {
+			result.Add(elem, "", CodeTypeMismatch, fmt.Sprintf("field %s (%s) can't hold a %s value", field.Name, field.Type, elem.Type))
+		}
+	}
+	ids := []string{}
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if field := fields[id]; !model.HasElement(id) {
+			result.Errors = append(result.Errors, &ValidationError{
+				ElementId: id,
+				Code:      CodeUnexpectedField,
+				Message:   fmt.Sprintf("field %s is tagged %q which is not an element of %s", field.Name, id, model.Id),
+			})
+		}
+	}
+	return result.Err()
+}
+
+// Bind validates form data with the model's validators then populates the
+// struct pointed to by target. Struct fields are matched to element ids using
+// a `model:"id"` struct tag. An error is returned if the struct doesn't match
+// the model (see CheckStruct), the data doesn't validate or a value can't be
+// assigned to its field.
+//
+// ```
+//
+//	type Person struct {
+//	    Id     string    `model:"id"`
+//	    Family string    `model:"family"`
+//	    Born   time.Time `model:"born"`
+//	}
+//
+//	person := new(Person)
+//	if err := model.Bind(formData, person); err != nil {
+//	    ... // handle error
+//	}
+//
+// ```
+func (model *Model) Bind(formData map[string]string, target interface{}) error {
+	if err := model.CheckStruct(target); err != nil {
+		return err
+	}
+	if err := model.ValidateReport(formData).Err(); err != nil {
+		return err
+	}
+	record, err := model.Decode(formData)
+	if err != nil {
+		return err
+	}
+	rv, fields, _ := boundFields(target)
+	result := new(ValidationResult)
+	for _, elem := range model.Elements {
+		field, ok := fields[elem.Id]
+		if !ok {
+			continue
+		}
+		val, ok := record[elem.Id]
+		if !ok || val == nil {
+			continue
+		}
+		fv := rv.FieldByIndex(field.Index)
+		if err := assignValue(fv, val, formData[elem.Id]); err != nil {
+			result.Add(elem, formData[elem.Id], CodeTypeMismatch, fmt.Sprintf("can't assign to field %s, %s", field.Name, err))
+		}
+	}
+	return result.Err()
+}
+
+// assignValue sets the field to the decoded value. The form value is used
+// when the field is a string.
+func assignValue(fv reflect.Value, val interface{}, formValue string) error {
+	if fv.Kind() == reflect.Ptr {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := assignValue(ptr.Elem(), val, formValue); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+	rv := reflect.ValueOf(val)
+	switch fv.Kind() {
+	case reflect.String:
+		if s, ok := val.(string); ok {
+			fv.SetString(s)
+		} else {
+			fv.SetString(formValue)
+		}
+		return nil
+	case reflect.Interface:
+		fv.Set(rv)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch n := val.(type) {
+		case int64:
+			i = n
+		case float64:
+			if n != math.Trunc(n) {
+				return fmt.Errorf("%v is not a whole number", n)
+			}
+			i = int64(n)
+		default:
+			return fmt.Errorf("%T is not a number", val)
+		}
+		if fv.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %s", i, fv.Type())
+		}
+		fv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch n := val.(type) {
+		case int64:
+			if n < 0 {
+				return fmt.Errorf("%d is negative", n)
+			}
+			u = uint64(n)
+		case float64:
+			if n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%v is not a positive whole number", n)
+			}
+			u = uint64(n)
+		default:
+			return fmt.Errorf("%T is not a number", val)
+		}
+		if fv.OverflowUint(u) {
+			return fmt.Errorf("%d overflows %s", u, fv.Type())
+		}
+		fv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := val.(type) {
+		case int64:
+			f = float64(n)
+		case float64:
+			f = n
+		default:
+			return fmt.Errorf("%T is not a number", val)
+		}
+		if fv.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %s", f, fv.Type())
+		}
+		fv.SetFloat(f)
+		return nil
+	}
+	if rv.Type().AssignableTo(fv.Type()) {
+		fv.Set(rv)
+		return nil
+	}
+	return fmt.Errorf("%T is not assignable to %s", val, fv.Type())
+}
@@ -0,0 +1,134 @@
+// bind_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this list of conditions and
+//     the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//     and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//     promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES,
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"testing"
+	"time"
+
+	// 3rd Party packages
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// TestBind tests populating a Go struct from form data.
+func TestBind(t *testing.T) {
+	src := []byte(`id: test_bind
+description: This is a test of binding form data to a struct
+elements:
+  - id: pid
+    type: uuid
+    is_primary_id: true
+  - id: title
+    type: text
+    attributes:
+      required: true
+  - id: published
+    type: date
+  - id: pages
+    type: number
+  - id: peer_reviewed
+    type: checkbox
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+
+	type Article struct {
+		Pid          uuid.UUID  `model:"pid"`
+		Title        string     `model:"title"`
+		Published    *time.Time `model:"published"`
+		Pages        int        `model:"pages"`
+		PeerReviewed bool       `model:"peer_reviewed"`
+		Notes        string
+	}
+	formData := map[string]string{
+		"pid":           "01925416-3e1a-77a5-9cf5-7452554913c8",
+		"title":         "A title",
+		"published":     "2024-10-03",
+		"pages":         "312",
+		"peer_reviewed": "on",
+	}
+	article := new(Article)
+	if err := model.Bind(formData, article); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if article.Pid.String() != formData["pid"] {
+		t.Errorf("expected pid %q, got %q", formData["pid"], article.Pid)
+	}
+	if article.Title != formData["title"] {
+		t.Errorf("expected title %q, got %q", formData["title"], article.Title)
+	}
+	if article.Published == nil || article.Published.Format("2006-01-02") != formData["published"] {
+		t.Errorf("expected published %q, got %+v", formData["published"], article.Published)
+	}
+	if article.Pages != 312 || !article.PeerReviewed {
+		t.Errorf("expected pages 312 and peer reviewed, got %+v", article)
+	}
+
+	// Invalid data should not bind
+	formData["published"] = "October 3rd"
+	if err := model.Bind(formData, new(Article)); err == nil {
+		t.Errorf("expected an error binding published %q", formData["published"])
+	}
+
+	// Struct doesn't agree with the model
+	type Mismatch struct {
+		Pid       string `model:"pid"`
+		Title     int    `model:"title"`
+		Published string `model:"published"`
+		Pages     bool   `model:"pages"`
+		Subtitle  string `model:"subtitle"`
+	}
+	err := model.CheckStruct(new(Mismatch))
+	if err == nil {
+		t.Errorf("expected CheckStruct to report mismatches")
+		t.FailNow()
+	}
+	expected := map[string]string{
+		"title":         CodeTypeMismatch,
+		"pages":         CodeTypeMismatch,
+		"peer_reviewed": CodeUnboundElement,
+		"subtitle":      CodeUnexpectedField,
+	}
+	result := err.(*ValidationResult)
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d mismatches, got %s", len(expected), result)
+	}
+	for _, vErr := range result.Errors {
+		if code, ok := expected[vErr.ElementId]; !ok || code != vErr.Code {
+			t.Errorf("unexpected mismatch %+v", vErr)
+		}
+	}
+	if err := model.Bind(formData, Article{}); err == nil {
+		t.Errorf("expected an error binding to a non-pointer")
+	}
+}
@@ -0,0 +1,332 @@
+// bounds.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// This file holds the code used to check values against an element's min, max
+// and step attributes. Each type expresses min, max and step in its own units,
+// e.g. days for date, months for month, weeks for week and seconds for time and
+// datetime-local. Like the browser, step is only enforced when the element has
+// a step attribute (not set to "any").
+//
+
+// boundsError is returned when a value is outside its element's min and max or
+// doesn't match the element's step.
+type boundsError struct {
+	code string
+	msg  string
+}
+
+// Error implements the error interface
+func (e *boundsError) Error() string {
+	return e.msg
+}
+
+var (
+	reWeek = regexp.MustCompile(`^([0-9]{4,})-W([0-9]{2})$`)
+
+	// boundsCheckers maps types to the func used to check min, max and step.
+	boundsCheckers = map[string]func(*Element, string) error{
+		"date":           checkDateBounds,
+		"datetime-local": checkDateTimeLocalBounds,
+		"month":          checkMonthBounds,
+		"time":           checkTimeBounds,
+		"week":           checkWeekBounds,
+		"number": func(elem *Element, formValue string) error {
+			return checkNumberBounds(elem, formValue, false)
+		},
+		"range": func(elem *Element, formValue string) error {
+			return checkNumberBounds(elem, formValue, true)
+		},
+	}
+)
+
+// getStep returns the element's step attribute. It returns false if the
+// step is not set or is "any".
+func getStep(elem *Element) (float64, bool, error) {
+	val, ok := elem.Attributes["step"]
+	if !ok || strings.TrimSpace(val) == "" || strings.ToLower(strings.TrimSpace(val)) == "any" {
+		return 0, false, nil
+	}
+	step, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || step <= 0 {
+		return 0, false, fmt.Errorf("invalid step attribute %q", val)
+	}
+	return step, true, nil
+}
+
+// checkUnits checks a value expressed in a type's units against the element's min, max
+// and step attributes. toUnits converts the min and max attribute values into the same
+// units, defaultBase is the step base used when min is not set.
+func checkUnits(elem *Element, value float64, toUnits func(string) (float64, error), defaultBase float64) error {
+	base := defaultBase
+	if val, ok := elem.Attributes["min"]; ok && val != "" {
+		minVal, err := toUnits(val)
+		if err != nil {
+			return fmt.Errorf("invalid min attribute %q, %s", val, err)
+		}
+		if value < minVal {
+			return &boundsError{CodeOutOfRange, fmt.Sprintf("value is less than min %s", val)}
+		}
+		base = minVal
+	}
+	if val, ok := elem.Attributes["max"]; ok && val != "" {
+		maxVal, err := toUnits(val)
+		if err != nil {
+			return fmt.Errorf("invalid max attribute %q, %s", val, err)
+		}
+		if value > maxVal {
+			return &boundsError{CodeOutOfRange, fmt.Sprintf("value is greater than max %s", val)}
+		}
+	}
+	step, ok, err := getStep(elem)
+	if err != nil {
+		return err
+	}
+	if ok && isStepMismatch(value, base, step) {
+		return &boundsError{CodeStepMismatch, fmt.Sprintf("value does not match step %s", elem.Attributes["step"])}
+	}
+	return nil
+}
+
+// isStepMismatch checks if value is a whole number of steps from the base.
+func isStepMismatch(value float64, base float64, step float64) bool {
+	r := (value - base) / step
+	return math.Abs(r-math.Round(r)) > 1e-7
+}
+
+// dateToDays converts a YYYY-MM-DD date into days since 1970-01-01.
+func dateToDays(s string) (float64, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(t.Unix() / 86400), nil
+}
+
+// checkDateBounds validates a date against the element's min, max and step (in days).
+func checkDateBounds(elem *Element, formValue string) error {
+	days, err := dateToDays(formValue)
+	if err != nil {
+		return err
+	}
+	return checkUnits(elem, days, dateToDays, 0)
+}
+
+// monthToMonths converts a YYYY-MM month into months since 1970-01.
+func monthToMonths(s string) (float64, error) {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return 0, err
+	}
+	return float64((t.Year()-1970)*12 + int(t.Month()) - 1), nil
+}
+
+// checkMonthBounds validates a month against the element's min, max and step (in months).
+func checkMonthBounds(elem *Element, formValue string) error {
+	months, err := monthToMonths(formValue)
+	if err != nil {
+		return err
+	}
+	return checkUnits(elem, months, monthToMonths, 0)
+}
+
+// weeksInYear returns the number of ISO 8601 weeks in a year, 52 or 53.
+func weeksInYear(year int) int {
+	// December 28th is always in the last week of the year.
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// parseWeek parses an ISO 8601 week string (YYYY-Www) returning the
+// Monday that starts the week.
+func parseWeek(s string) (time.Time, error) {
+	m := reWeek.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("%q is not formatted as YYYY-Www", s)
+	}
+	year, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+	if week < 1 || week > weeksInYear(year) {
+		return time.Time{}, fmt.Errorf("%d does not have week %d", year, week)
+	}
+	// January 4th is always in the first week of the year
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7), nil
+}
+
+// weekToWeeks converts a YYYY-Www week into weeks since 1970-W01.
+func weekToWeeks(s string) (float64, error) {
+	t, err := parseWeek(s)
+	if err != nil {
+		return 0, err
+	}
+	// 1970-W01 starts on Monday, December 29th, 1969, three days before the epoch
+	return float64((t.Unix()/86400 + 3) / 7), nil
+}
+
+// checkWeekBounds validates a week against the element's min, max and step (in weeks).
+func checkWeekBounds(elem *Element, formValue string) error {
+	weeks, err := weekToWeeks(formValue)
+	if err != nil {
+		return err
+	}
+	return checkUnits(elem, weeks, weekToWeeks, 0)
+}
+
+// parseTime parses a HTML time string, HH:MM, HH:MM:SS or HH:MM:SS.sss
+func parseTime(s string) (time.Time, error) {
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range []string{"15:04", "15:04:05", "15:04:05.999999999"} {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// timeToSeconds converts a time string into seconds since midnight.
+func timeToSeconds(s string) (float64, error) {
+	t, err := parseTime(s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(t.Hour()*3600+t.Minute()*60+t.Second()) + float64(t.Nanosecond())/1e9, nil
+}
+
+// checkTimeBounds validates a time against the element's min, max and step (in seconds).
+// If max is before min the range wraps past midnight, e.g. min 22:00 max 02:00.
+func checkTimeBounds(elem *Element, formValue string) error {
+	seconds, err := timeToSeconds(formValue)
+	if err != nil {
+		return err
+	}
+	minVal, hasMin := elem.Attributes["min"]
+	maxVal, hasMax := elem.Attributes["max"]
+	if hasMin && hasMax {
+		minSeconds, err := timeToSeconds(minVal)
+		if err != nil {
+			return fmt.Errorf("invalid min attribute %q, %s", minVal, err)
+		}
+		maxSeconds, err := timeToSeconds(maxVal)
+		if err != nil {
+			return fmt.Errorf("invalid max attribute %q, %s", maxVal, err)
+		}
+		if maxSeconds < minSeconds {
+			if seconds < minSeconds && seconds > maxSeconds {
+				return &boundsError{CodeOutOfRange, fmt.Sprintf("value is not between %s and %s", minVal, maxVal)}
+			}
+			step, ok, err := getStep(elem)
+			if err != nil {
+				return err
+			}
+			if ok && isStepMismatch(seconds, minSeconds, step) {
+				return &boundsError{CodeStepMismatch, fmt.Sprintf("value does not match step %s", elem.Attributes["step"])}
+			}
+			return nil
+		}
+	}
+	return checkUnits(elem, seconds, timeToSeconds, 0)
+}
+
+// checkDateTimeLocalBounds validates a local date and time against the element's min, max
+// and step (in seconds). The step is checked using whole nanoseconds to avoid floating
+// point rounding on large values.
+func checkDateTimeLocalBounds(elem *Element, formValue string) error {
+	t, err := parseDateTimeLocal(formValue)
+	if err != nil {
+		return err
+	}
+	base := time.Unix(0, 0).In(t.Location())
+	if val, ok := elem.Attributes["min"]; ok && val != "" {
+		minTime, err := parseDateTimeLocal(val)
+		if err != nil {
+			return fmt.Errorf("invalid min attribute %q, %s", val, err)
+		}
+		if t.Before(minTime) {
+			return &boundsError{CodeOutOfRange, fmt.Sprintf("value is before min %s", val)}
+		}
+		base = minTime
+	}
+	if val, ok := elem.Attributes["max"]; ok && val != "" {
+		maxTime, err := parseDateTimeLocal(val)
+		if err != nil {
+			return fmt.Errorf("invalid max attribute %q, %s", val, err)
+		}
+		if t.After(maxTime) {
+			return &boundsError{CodeOutOfRange, fmt.Sprintf("value is after max %s", val)}
+		}
+	}
+	step, ok, err := getStep(elem)
+	if err != nil {
+		return err
+	}
+	if ok {
+		stepNs := time.Duration(math.Round(step * float64(time.Second)))
+		if stepNs > 0 && t.Sub(base)%stepNs != 0 {
+			return &boundsError{CodeStepMismatch, fmt.Sprintf("value does not match step %s", elem.Attributes["step"])}
+		}
+	}
+	return nil
+}
+
+// checkNumberBounds validates a number against the element's min, max and step. The step
+// base is min, if not set the element's value attribute then zero. When isRange is true
+// min and max default to 0 and 100 like the browser's range input.
+func checkNumberBounds(elem *Element, formValue string, isRange bool) error {
+	number, err := jsonDecodeNumber(formValue)
+	if err != nil {
+		return err
+	}
+	if isRange {
+		attr := map[string]string{"min": "0", "max": "100"}
+		for k, v := range elem.Attributes {
+			attr[k] = v
+		}
+		elem = &Element{Id: elem.Id, Type: elem.Type, Attributes: attr}
+	}
+	base := 0.0
+	if val, ok := elem.Attributes["value"]; ok {
+		if n, err := jsonDecodeNumber(val); err == nil {
+			base = n
+		}
+	}
+	return checkUnits(elem, number, jsonDecodeNumber, base)
+}
@@ -0,0 +1,94 @@
+// checksum.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// crockfordAlphabet holds the digits of Douglas Crockford's base 32 encoding,
+	// see <https://www.crockford.com/base32.html>. It leaves out i, l, o and u.
+	crockfordAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+)
+
+// crockfordDecode decodes a Crockford base 32 string into an integer. Case is ignored,
+// hyphens are skipped and the easily confused letters i, l and o are read as 1, 1 and 0.
+func crockfordDecode(src string) (uint64, error) {
+	var n uint64
+	for _, c := range strings.ToLower(src) {
+		switch c {
+		case '-':
+			continue
+		case 'i', 'l':
+			c = '1'
+		case 'o':
+			c = '0'
+		}
+		d := strings.IndexRune(crockfordAlphabet, c)
+		if d < 0 {
+			return 0, fmt.Errorf("%q is not a Crockford base 32 digit", c)
+		}
+		if n > (^uint64(0))>>5 {
+			return 0, fmt.Errorf("%q is too large to decode", src)
+		}
+		n = n*32 + uint64(d)
+	}
+	return n, nil
+}
+
+// rorChecksum calculates the two digit checksum for the seven character base 32
+// part of a ROR id (the leading zero and six characters). ROR uses the ISO 7064
+// MOD 97-10 check, see <https://ror.readme.io/docs/identifier>.
+func rorChecksum(src string) (string, error) {
+	n, err := crockfordDecode(src)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", 98-((n*100)%97)), nil
+}
+
+// mod11_2CheckDigit calculates the ISO 7064 MOD 11-2 check character used by ISNI and
+// ORCID from the fifteen leading digits. A check value of ten is written as "X".
+func mod11_2CheckDigit(digits string) (string, error) {
+	if len(digits) != 15 {
+		return "", fmt.Errorf("expected 15 digits, got %d", len(digits))
+	}
+	total := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("%q is not a digit", c)
+		}
+		total = (total + int(c-'0')) * 2
+	}
+	ck := (12 - total%11) % 11
+	if ck == 10 {
+		return "X", nil
+	}
+	return fmt.Sprintf("%d", ck), nil
+}
@@ -210,7 +210,6 @@ func main() {
 		}
 		defer fout.Close()
 
-		models.SetDefaultTypes(model)
 		model.Register("yaml", models.ModelToYAML)
 		if err := models.ModelInteractively(model); err != nil {
 			fmt.Fprintf(eout, "ERROR: %s\n", err)
@@ -0,0 +1,120 @@
+// constraints.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+//
+// The constraint layer applies the HTML constraint attributes shared by all
+// element types (required, minlength, maxlength and pattern). It runs before
+// the type's ValidateFunc so custom types defined with Model.Define get the
+// same treatment as the default types.
+//
+
+var (
+	// patterns caches compiled element patterns, key is the pattern source.
+	patterns sync.Map
+)
+
+// compilePattern compiles an HTML pattern attribute. Like the browser the
+// pattern must match the entire value.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// getPattern returns the element's pattern, the Pattern field takes precedence
+// over a "pattern" attribute.
+func getPattern(elem *Element) string {
+	if elem.Pattern != "" {
+		return elem.Pattern
+	}
+	return elem.Attributes["pattern"]
+}
+
+// getLength returns the value of a length attribute (e.g. minlength) and true
+// if it is set to a non-negative integer.
+func getLength(elem *Element, attr string) (int, bool) {
+	val, ok := elem.Attributes[attr]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		if Debug {
+			log.Printf("DEBUG ignoring elem.Id %q, %s attribute %q", elem.Id, attr, val)
+		}
+		return 0, false
+	}
+	return n, true
+}
+
+// checkConstraints checks a value against the element's required, minlength,
+// maxlength and pattern attributes. It returns the failure code and message,
+// an empty code means the value passed. Like the browser an empty value is only
+// checked against required.
+func checkConstraints(elem *Element, value string) (string, string) {
+	if value == "" {
+		if elem.IsRequired() {
+			return CodeMissing, "a value is required"
+		}
+		return "", ""
+	}
+	length := utf8.RuneCountInString(value)
+	if n, ok := getLength(elem, "minlength"); ok && length < n {
+		return CodeTooShort, fmt.Sprintf("value must be at least %d characters", n)
+	}
+	if n, ok := getLength(elem, "maxlength"); ok && length > n {
+		return CodeTooLong, fmt.Sprintf("value must be at most %d characters", n)
+	}
+	if pattern := getPattern(elem); pattern != "" {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			// NOTE: the browser ignores invalid patterns so we do too.
+			if Debug {
+				log.Printf("DEBUG ignoring elem.Id %q, invalid pattern %q, %s", elem.Id, pattern, err)
+			}
+		} else if !re.MatchString(value) {
+			return CodePatternMismatch, fmt.Sprintf("value does not match pattern %q", pattern)
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,653 @@
+// edtf.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EDTF dates are described by the Library of Congress's Extended Date/Time Format
+// specification, <https://www.loc.gov/standards/datetime/>. Levels 0, 1 and 2 are
+// supported.
+
+const (
+	// maxEDTFYear limits the years (e.g. Y17E8) whose bounds are calculated
+	maxEDTFYear = 10000000000
+)
+
+// EDTF holds the result of parsing an Extended Date/Time Format value.
+type EDTF struct {
+	// Value is the EDTF value parsed
+	Value string `json:"value" yaml:"value"`
+
+	// Level is the lowest EDTF level (0, 1 or 2) supporting the features used by the value
+	Level int `json:"level" yaml:"level"`
+
+	// Earliest is the start of the earliest day (or instant for a date and time) the value
+	// covers. It is nil if the start is open or unknown, e.g. "../1985".
+	Earliest *time.Time `json:"earliest,omitempty" yaml:"earliest,omitempty"`
+
+	// Latest is the last second of the latest day (or instant for a date and time) the value
+	// covers. It is nil if the end is open or unknown, e.g. "1985/..".
+	Latest *time.Time `json:"latest,omitempty" yaml:"latest,omitempty"`
+}
+
+// edtfDate holds the bounds of a single EDTF date.
+type edtfDate struct {
+	earliest time.Time
+	latest   time.Time
+	level    int
+	hasTime  bool
+}
+
+// edtfSeasons maps the sub-year groupings (21 to 41) to their first and last month.
+// A last month before the first month falls in the following year. Seasons 21 to 24
+// are taken to be those of the northern hemisphere.
+var edtfSeasons = map[int][2]int{
+	21: {3, 5}, 22: {6, 8}, 23: {9, 11}, 24: {12, 2},
+	25: {3, 5}, 26: {6, 8}, 27: {9, 11}, 28: {12, 2},
+	29: {9, 11}, 30: {12, 2}, 31: {3, 5}, 32: {6, 8},
+	33: {1, 3}, 34: {4, 6}, 35: {7, 9}, 36: {10, 12},
+	37: {1, 4}, 38: {5, 8}, 39: {9, 12},
+	40: {1, 6}, 41: {7, 12},
+}
+
+// ParseEDTF parses a date, date and time, interval or set written in the Extended Date/Time
+// Format. The result holds the earliest and latest bounds of the value for sorting and
+// range queries. Qualifiers (uncertain "?", approximate "~" or both "%") don't change the
+// bounds, unspecified digits ("X") and significant digits ("S") widen them.
+func ParseEDTF(value string) (*EDTF, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing EDTF value")
+	}
+	result := &EDTF{Value: value}
+	switch {
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+		if err := result.parseSet(value); err != nil {
+			return nil, err
+		}
+	case strings.Contains(value, "/"):
+		if err := result.parseInterval(value); err != nil {
+			return nil, err
+		}
+	default:
+		d, err := parseEDTFDate(value)
+		if err != nil {
+			return nil, err
+		}
+		result.Level = d.level
+		result.Earliest, result.Latest = &d.earliest, &d.latest
+	}
+	return result, nil
+}
+
+// parseInterval parses a start and end separated by "/". Either side can be
+// unknown (empty) or open ("..") but not both.
+func (e *EDTF) parseInterval(value string) error {
+	start, end, _ := strings.Cut(value, "/")
+	if (start == "" || start == "..") && (end == "" || end == "..") {
+		return fmt.Errorf("%q is missing both a start and an end", value)
+	}
+	var first, last *edtfDate
+	for i, part := range []string{start, end} {
+		if part == "" || part == ".." {
+			e.Level = max(e.Level, 1)
+			continue
+		}
+		d, err := parseEDTFDate(part)
+		if err != nil {
+			return err
+		}
+		if d.hasTime {
+			return fmt.Errorf("%q, an interval can't include a time", value)
+		}
+		e.Level = max(e.Level, d.level)
+		if i == 0 {
+			first = d
+			e.Earliest = &d.earliest
+		} else {
+			last = d
+			e.Latest = &d.latest
+		}
+	}
+	if first != nil && last != nil && first.earliest.After(last.latest) {
+		return fmt.Errorf("%q ends before it starts", value)
+	}
+	return nil
+}
+
+// parseSet parses a set of dates, "[...]" for one of the dates or "{...}" for all of
+// them. Members are separated by commas and can be ranges, e.g. "1670..1672". The first
+// member can be open at the start ("..1760") and the last open at the end ("1760..").
+func (e *EDTF) parseSet(value string) error {
+	closing := "]"
+	if value[0] == '{' {
+		closing = "}"
+	}
+	if !strings.HasSuffix(value, closing) || len(value) < 3 {
+		return fmt.Errorf("%q is not a set", value)
+	}
+	e.Level = 2
+	members := strings.Split(value[1:len(value)-1], ",")
+	openStart, openEnd := false, false
+	var earliest, latest time.Time
+	found := false
+	for i, member := range members {
+		from, to, isRange := strings.Cut(member, "..")
+		switch {
+		case isRange && from == "" && to == "":
+			return fmt.Errorf("%q has an empty range", value)
+		case isRange && from == "":
+			if i != 0 {
+				return fmt.Errorf("%q, only the first member can be open at the start", value)
+			}
+			openStart = true
+		case isRange && to == "":
+			if i != len(members)-1 {
+				return fmt.Errorf("%q, only the last member can be open at the end", value)
+			}
+			openEnd = true
+		}
+		var first, last *edtfDate
+		for j, part := range []string{from, to} {
+			if part == "" {
+				continue
+			}
+			d, err := parseEDTFDate(part)
+			if err != nil {
+				return err
+			}
+			if d.hasTime {
+				return fmt.Errorf("%q, a set can't include a time", value)
+			}
+			if !found || d.earliest.Before(earliest) {
+				earliest = d.earliest
+			}
+			if !found || d.latest.After(latest) {
+				latest = d.latest
+			}
+			found = true
+			if j == 0 {
+				first = d
+			} else {
+				last = d
+			}
+		}
+		if isRange && first != nil && last != nil && first.earliest.After(last.latest) {
+			return fmt.Errorf("%q has a range that ends before it starts", value)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%q is an empty set", value)
+	}
+	if !openStart {
+		e.Earliest = &earliest
+	}
+	if !openEnd {
+		e.Latest = &latest
+	}
+	return nil
+}
+
+// edtfComponent is a year, month or day of an EDTF date along with its qualifiers.
+type edtfComponent struct {
+	digits string
+	prefix byte
+	suffix byte
+}
+
+// isEDTFQualifier checks for the uncertain, approximate and uncertain and approximate qualifiers.
+func isEDTFQualifier(c byte) bool {
+	return c == '?' || c == '~' || c == '%'
+}
+
+// parseEDTFDate parses a single date (or date and time) returning its bounds and level.
+func parseEDTFDate(value string) (*edtfDate, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing EDTF date")
+	}
+	if value[0] == 'Y' {
+		return parseEDTFLongYear(value)
+	}
+	if strings.Contains(value, "T") {
+		return parseEDTFDateTime(value)
+	}
+	// Read the year, month and day along with their qualifiers
+	components := []*edtfComponent{}
+	negative, significant := false, 0
+	pos := 0
+	for len(components) < 3 {
+		c := new(edtfComponent)
+		if pos < len(value) && isEDTFQualifier(value[pos]) {
+			c.prefix = value[pos]
+			pos++
+		}
+		if len(components) == 0 && pos < len(value) && value[pos] == '-' {
+			negative = true
+			pos++
+		}
+		size := 2
+		if len(components) == 0 {
+			size = 4
+		}
+		if pos+size > len(value) {
+			return nil, fmt.Errorf("%q is not an EDTF date", value)
+		}
+		c.digits = value[pos : pos+size]
+		for i := 0; i < size; i++ {
+			if !(c.digits[i] >= '0' && c.digits[i] <= '9') && c.digits[i] != 'X' {
+				return nil, fmt.Errorf("%q is not an EDTF date", value)
+			}
+		}
+		pos += size
+		if pos < len(value) && isEDTFQualifier(value[pos]) {
+			c.suffix = value[pos]
+			pos++
+		}
+		components = append(components, c)
+		if len(components) == 1 && pos < len(value) && value[pos] == 'S' {
+			n, err := strconv.Atoi(value[pos+1:])
+			if err != nil || n < 1 || n > 4 {
+				return nil, fmt.Errorf("%q has invalid significant digits", value)
+			}
+			significant = n
+			pos = len(value)
+		}
+		if pos == len(value) {
+			break
+		}
+		if value[pos] != '-' {
+			return nil, fmt.Errorf("%q is not an EDTF date", value)
+		}
+		pos++
+	}
+	if pos != len(value) {
+		return nil, fmt.Errorf("%q is not an EDTF date", value)
+	}
+	d := &edtfDate{}
+	if err := d.setBounds(value, components, negative, significant); err != nil {
+		return nil, err
+	}
+	d.level = edtfDateLevel(components, negative, significant)
+	return d, nil
+}
+
+// edtfDateLevel works out the lowest EDTF level supporting a date.
+func edtfDateLevel(components []*edtfComponent, negative bool, significant int) int {
+	level := 0
+	if negative {
+		level = 1
+	}
+	last := len(components) - 1
+	for i, c := range components {
+		// Level 1 only qualifies the whole date, i.e. a qualifier at the end
+		if c.prefix != 0 || (c.suffix != 0 && i != last) {
+			return 2
+		}
+		if c.suffix != 0 {
+			level = 1
+		}
+	}
+	if significant > 0 {
+		return 2
+	}
+	if last > 0 {
+		if month, err := strconv.Atoi(components[1].digits); err == nil && month > 12 {
+			if month > 24 {
+				return 2
+			}
+			level = 1
+		}
+	}
+	// Level 1 allows unspecified digits at the end of a year (201X, 20XX) or whole
+	// months and days of a known year (2004-XX, 1985-04-XX, 1985-XX-XX).
+	masks := ""
+	for _, c := range components {
+		masks += c.digits + "-"
+	}
+	if !strings.Contains(masks, "X") {
+		return level
+	}
+	year := components[0].digits
+	switch {
+	case last == 0:
+		known := strings.TrimRight(year, "X")
+		if len(known) >= 2 && !strings.Contains(known, "X") {
+			return max(level, 1)
+		}
+	case last > 0 && !strings.Contains(year, "X"):
+		month := components[1].digits
+		if month == "XX" && (last == 1 || components[2].digits == "XX") {
+			return max(level, 1)
+		}
+		if !strings.Contains(month, "X") && last == 2 && components[2].digits == "XX" {
+			return max(level, 1)
+		}
+	}
+	return 2
+}
+
+// matchesEDTFMask checks if a number matches digits where "X" stands for any digit.
+func matchesEDTFMask(mask string, n int) bool {
+	s := fmt.Sprintf("%0*d", len(mask), n)
+	if len(s) != len(mask) {
+		return false
+	}
+	for i := 0; i < len(mask); i++ {
+		if mask[i] != 'X' && mask[i] != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// edtfMaskValue fills the "X" digits of a mask with the digits of k, e.g. mask
+// "19X5" and k 3 gives 1935. Counting k up from 0 gives the values the mask
+// allows in increasing order.
+func edtfMaskValue(mask string, k int) int {
+	digits := []byte(mask)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] == 'X' {
+			digits[i] = byte('0' + k%10)
+			k /= 10
+		}
+	}
+	n, _ := strconv.Atoi(string(digits))
+	return n
+}
+
+// setBounds works out the earliest and latest days a date covers. The year's "X"
+// digits become 0 for the lowest year and 9 for the highest (swapped for a negative
+// year). The first (or last) month and day the masks allow that fall within the
+// month are taken, only trying the next year when the year has no such day (e.g.
+// February 29th).
+func (d *edtfDate) setBounds(value string, components []*edtfComponent, negative bool, significant int) error {
+	yearMask := components[0].digits
+	if significant > 0 {
+		if len(components) > 1 || strings.Contains(yearMask, "X") {
+			return fmt.Errorf("%q, significant digits only apply to a year", value)
+		}
+		year, _ := strconv.Atoi(yearMask)
+		unit := 1
+		for i := significant; i < len(yearMask); i++ {
+			unit *= 10
+		}
+		lo := (year / unit) * unit
+		hi := lo + unit - 1
+		if negative {
+			lo, hi = -hi, -lo
+		}
+		d.earliest = time.Date(lo, time.January, 1, 0, 0, 0, 0, time.UTC)
+		d.latest = time.Date(hi, time.December, 31, 23, 59, 59, 0, time.UTC)
+		return nil
+	}
+	// count is the number of years the mask allows, yearAt returns the i-th lowest of them
+	count := 1
+	for _, c := range yearMask {
+		if c == 'X' {
+			count *= 10
+		}
+	}
+	yearAt := func(i int) int {
+		if negative {
+			return -edtfMaskValue(yearMask, count-1-i)
+		}
+		return edtfMaskValue(yearMask, i)
+	}
+	monthMask, dayMask := "", ""
+	if len(components) > 1 {
+		monthMask = components[1].digits
+	}
+	if len(components) > 2 {
+		dayMask = components[2].digits
+	}
+	// A season (or other sub-year grouping) covers a span of months
+	if month, err := strconv.Atoi(monthMask); err == nil && month > 12 {
+		season, ok := edtfSeasons[month]
+		if !ok || dayMask != "" {
+			return fmt.Errorf("%q has an invalid month or season", value)
+		}
+		first, last := yearAt(0), yearAt(count-1)
+		d.earliest = time.Date(first, time.Month(season[0]), 1, 0, 0, 0, 0, time.UTC)
+		if season[1] < season[0] {
+			last++
+		}
+		d.latest = time.Date(last, time.Month(season[1]+1), 0, 23, 59, 59, 0, time.UTC)
+		return nil
+	}
+	months := []int{}
+	for m := 1; m <= 12; m++ {
+		if monthMask == "" || matchesEDTFMask(monthMask, m) {
+			months = append(months, m)
+		}
+	}
+	if len(months) == 0 {
+		return fmt.Errorf("%q has an invalid month", value)
+	}
+	days := []int{}
+	for day := 1; day <= 31; day++ {
+		if dayMask == "" || matchesEDTFMask(dayMask, day) {
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return fmt.Errorf("%q has an invalid day", value)
+	}
+	// firstDay returns the first (or last) day the month and day masks allow in a year
+	firstDay := func(year int, last bool) (time.Time, bool) {
+		for i := range months {
+			m := months[i]
+			if last {
+				m = months[len(months)-1-i]
+			}
+			// NOTE: day 0 of the following month is the month's last day
+			length := time.Date(year, time.Month(m)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			for j := range days {
+				day := days[j]
+				if last {
+					day = days[len(days)-1-j]
+				}
+				if day <= length {
+					return time.Date(year, time.Month(m), day, 0, 0, 0, 0, time.UTC), true
+				}
+			}
+		}
+		return time.Time{}, false
+	}
+	// NOTE: a day missing from the months of a leap year (e.g. February 30th) is missing from every year
+	if _, ok := firstDay(2000, false); !ok {
+		return fmt.Errorf("%q has an invalid day", value)
+	}
+	found := false
+	for i := 0; i < count && !found; i++ {
+		d.earliest, found = firstDay(yearAt(i), false)
+	}
+	if !found {
+		return fmt.Errorf("%q has an invalid day", value)
+	}
+	found = false
+	for i := count - 1; i >= 0 && !found; i-- {
+		d.latest, found = firstDay(yearAt(i), true)
+	}
+	d.latest = d.latest.Add(24*time.Hour - time.Second)
+	return nil
+}
+
+// parseEDTFLongYear parses a year starting with "Y". At level 1 the year has more than
+// four digits, e.g. Y170000002. Level 2 adds an exponent and significant digits, e.g.
+// Y-17E7 and Y171010000S3.
+func parseEDTFLongYear(value string) (*edtfDate, error) {
+	val := value[1:]
+	negative := strings.HasPrefix(val, "-")
+	val = strings.TrimPrefix(val, "-")
+	level := 1
+	significant := 0
+	if s, n, ok := strings.Cut(val, "S"); ok {
+		i, err := strconv.Atoi(n)
+		if err != nil || i < 1 {
+			return nil, fmt.Errorf("%q has invalid significant digits", value)
+		}
+		val, significant, level = s, i, 2
+	}
+	digits, exponent, hasExponent := strings.Cut(val, "E")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, fmt.Errorf("%q is not an EDTF year", value)
+	}
+	year, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || year > maxEDTFYear {
+		return nil, fmt.Errorf("%q is not a supported EDTF year", value)
+	}
+	if hasExponent {
+		e, err := strconv.Atoi(exponent)
+		if err != nil || e < 1 || strings.Trim(exponent, "0123456789") != "" {
+			return nil, fmt.Errorf("%q has an invalid exponent", value)
+		}
+		for ; e > 0; e-- {
+			year *= 10
+			if year > maxEDTFYear {
+				return nil, fmt.Errorf("%q is not a supported EDTF year", value)
+			}
+		}
+		level = 2
+	} else if len(digits) <= 4 {
+		return nil, fmt.Errorf("%q, a year starting with Y must have more than four digits", value)
+	}
+	lo, hi := year, year
+	if significant > 0 {
+		n := len(strconv.FormatInt(year, 10))
+		if significant > n {
+			return nil, fmt.Errorf("%q has more significant digits than the year", value)
+		}
+		unit := int64(1)
+		for i := significant; i < n; i++ {
+			unit *= 10
+		}
+		lo = (year / unit) * unit
+		hi = lo + unit - 1
+	}
+	if negative {
+		lo, hi = -hi, -lo
+	}
+	return &edtfDate{
+		earliest: time.Date(int(lo), time.January, 1, 0, 0, 0, 0, time.UTC),
+		latest:   time.Date(int(hi), time.December, 31, 23, 59, 59, 0, time.UTC),
+		level:    level,
+	}, nil
+}
+
+// parseEDTFDateTime parses a level 0 date and time, e.g. 1985-04-12T23:20:30 with
+// an optional "Z" or offset (e.g. "-04", "+04:30").
+func parseEDTFDateTime(value string) (*edtfDate, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05Z07"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &edtfDate{earliest: t, latest: t, hasTime: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not an EDTF date and time", value)
+}
+
+// formatEDTFBound formats a bound as an ISO 8601 date, years outside 0000 to 9999
+// have a sign, e.g. "-0044-03-15".
+func formatEDTFBound(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	if t.Year() < 0 || t.Year() > 9999 {
+		return fmt.Sprintf("%+05d-%02d-%02d", t.Year(), t.Month(), t.Day())
+	}
+	return t.Format("2006-01-02")
+}
+
+// EarliestDate returns the earliest bound as an ISO 8601 date or an empty string if the start is open or unknown.
+func (e *EDTF) EarliestDate() string {
+	return formatEDTFBound(e.Earliest)
+}
+
+// LatestDate returns the latest bound as an ISO 8601 date or an empty string if the end is open or unknown.
+func (e *EDTF) LatestDate() string {
+	return formatEDTFBound(e.Latest)
+}
+
+// GenerateEDTF sets up an HTML input type text for an Extended Date/Time Format value
+// with help text describing the format.
+func GenerateEDTF() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"placeholder": "e.g. 1950~, 19XX, 1985-04/1990 or 2004-06/..",
+			"title":       "An EDTF date: YYYY, YYYY-MM or YYYY-MM-DD. Add ? if uncertain, ~ if approximate or % if both. Use X for unknown digits, / between the start and end of an interval and .. for an open start or end.",
+		},
+	}
+}
+
+// ValidateEDTF checks a value is an Extended Date/Time Format date, date and time,
+// interval or set at level 0, 1 or 2.
+func ValidateEDTF(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, err := ParseEDTF(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+// DeriveEDTFEarliest returns the earliest bound of an EDTF value as an ISO 8601 date. It
+// derives the "_earliest" column of an edtf element.
+func DeriveEDTFEarliest(elem *Element, formValue string) (interface{}, error) {
+	e, err := ParseEDTF(formValue)
+	if err != nil {
+		return nil, err
+	}
+	if e.Earliest == nil {
+		return nil, nil
+	}
+	return e.EarliestDate(), nil
+}
+
+// DeriveEDTFLatest returns the latest bound of an EDTF value as an ISO 8601 date. It
+// derives the "_latest" column of an edtf element.
+func DeriveEDTFLatest(elem *Element, formValue string) (interface{}, error) {
+	e, err := ParseEDTF(formValue)
+	if err != nil {
+		return nil, err
+	}
+	if e.Latest == nil {
+		return nil, nil
+	}
+	return e.LatestDate(), nil
+}
@@ -0,0 +1,167 @@
+// edtf_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestParseEDTF tests parsing the examples of the EDTF specification levels 0, 1 and 2.
+func TestParseEDTF(t *testing.T) {
+	// value -> level, earliest and latest bounds
+	expected := map[string][3]string{
+		// Level 0
+		"1985":                 {"0", "1985-01-01", "1985-12-31"},
+		"1985-04":              {"0", "1985-04-01", "1985-04-30"},
+		"1985-04-12":           {"0", "1985-04-12", "1985-04-12"},
+		"1985-04-12T23:20:30Z": {"0", "1985-04-12", "1985-04-12"},
+		"1964/2008":            {"0", "1964-01-01", "2008-12-31"},
+		"2004-02-01/2005-02":   {"0", "2004-02-01", "2005-02-28"},
+		// Level 1
+		"Y170000002":     {"1", "+170000002-01-01", "+170000002-12-31"},
+		"-1985":          {"1", "-1985-01-01", "-1985-12-31"},
+		"2001-21":        {"1", "2001-03-01", "2001-05-31"},
+		"2001-24":        {"1", "2001-12-01", "2002-02-28"},
+		"1950~":          {"1", "1950-01-01", "1950-12-31"},
+		"2004-06-11%":    {"1", "2004-06-11", "2004-06-11"},
+		"19XX":           {"1", "1900-01-01", "1999-12-31"},
+		"201X":           {"1", "2010-01-01", "2019-12-31"},
+		"2004-XX":        {"1", "2004-01-01", "2004-12-31"},
+		"1985-04-XX":     {"1", "1985-04-01", "1985-04-30"},
+		"1985-XX-XX":     {"1", "1985-01-01", "1985-12-31"},
+		"1985-04/1990":   {"0", "1985-04-01", "1990-12-31"},
+		"2004-06/..":     {"1", "2004-06-01", ""},
+		"../1985-04-12":  {"1", "", "1985-04-12"},
+		"1985-04-12/":    {"1", "1985-04-12", ""},
+		"1984?/2004-06~": {"1", "1984-01-01", "2004-06-30"},
+		// Level 2
+		"Y-17E7":                  {"2", "-170000000-01-01", "-170000000-12-31"},
+		"1950S2":                  {"2", "1900-01-01", "1999-12-31"},
+		"Y171010000S3":            {"2", "+171000000-01-01", "+171999999-12-31"},
+		"2001-34":                 {"2", "2001-04-01", "2001-06-30"},
+		"[1667,1668,1670..1672]":  {"2", "1667-01-01", "1672-12-31"},
+		"[..1760-12-03]":          {"2", "", "1760-12-03"},
+		"[1760-12..]":             {"2", "1760-12-01", ""},
+		"{1960,1961-12}":          {"2", "1960-01-01", "1961-12-31"},
+		"2004?-06-11":             {"2", "2004-06-11", "2004-06-11"},
+		"2004-06~-11":             {"2", "2004-06-11", "2004-06-11"},
+		"?2004-06-~11":            {"2", "2004-06-11", "2004-06-11"},
+		"156X-12-25":              {"2", "1560-12-25", "1569-12-25"},
+		"XXXX-12-XX":              {"2", "0000-12-01", "9999-12-31"},
+		"1XXX-12":                 {"2", "1000-12-01", "1999-12-31"},
+		"1984-1X":                 {"2", "1984-10-01", "1984-12-31"},
+		"XXXX-02-29":              {"2", "0000-02-29", "9996-02-29"},
+		"198X-02-29":              {"2", "1980-02-29", "1988-02-29"},
+		"-198X-02-29":             {"2", "-1988-02-29", "-1980-02-29"},
+		"-XXXX-XX-XX":             {"2", "-9999-01-01", "0000-12-31"},
+		"-XXXX/-XXXX":             {"2", "-9999-01-01", "0000-12-31"},
+		"[-XXXX,-XXXX..XXXX]":     {"2", "-9999-01-01", "9999-12-31"},
+		"2004-06-~01/2004-06-~20": {"2", "2004-06-01", "2004-06-20"},
+	}
+	for val, e := range expected {
+		result, err := ParseEDTF(val)
+		if err != nil {
+			t.Errorf("expected %q to parse, %s", val, err)
+			continue
+		}
+		if level := string(rune('0' + result.Level)); level != e[0] {
+			t.Errorf("expected %q to be level %s, got %s", val, e[0], level)
+		}
+		if result.EarliestDate() != e[1] || result.LatestDate() != e[2] {
+			t.Errorf("expected %q to cover %q to %q, got %q to %q", val, e[1], e[2], result.EarliestDate(), result.LatestDate())
+		}
+	}
+	for _, val := range []string{"", "19", "1985-4", "1985-13", "1985-02-30", "2001-42", "2001-21-01", "Y1234", "1985/1984", "/", "../..",
+		"2004-06-11T25:00:00", "1985-04-12/2004-01-01T10:00:00", "[]", "[1667,..1668]", "[1672..1670]", "Y17E20", "2004-06-11!", "October 1985",
+		"XXXX-02-30", "XXX1-02-29", "1985-02-3X", "[1985,1672..1670]"} {
+		if _, err := ParseEDTF(val); err == nil {
+			t.Errorf("expected %q to fail to parse", val)
+		}
+	}
+}
+
+// TestEDTFElement tests validating and rendering an edtf element and deriving its bounds.
+func TestEDTFElement(t *testing.T) {
+	src := []byte(`id: test_edtf
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: created
+    type: edtf
+    label: Date created
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, val := range []string{"1950~", "19XX", "1985-04/1990", "2004-06/.."} {
+		if !model.Validate(map[string]string{"id": "one", "created": val}) {
+			t.Errorf("expected created %q to validate", val)
+		}
+	}
+	if model.Validate(map[string]string{"id": "one", "created": "circa 1950"}) {
+		t.Errorf("expected created %q to fail validation", "circa 1950")
+	}
+
+	columns, err := model.DeriveColumns(map[string]interface{}{"id": "one", "created": "2004-06/.."})
+	if err != nil {
+		t.Error(err)
+	}
+	if columns["created_earliest"] != "2004-06-01" || columns["created_latest"] != nil {
+		t.Errorf("expected created bounds 2004-06-01 and nil, got %+v", columns)
+	}
+	if _, err := model.DeriveColumns(map[string]interface{}{"created": "circa 1950"}); err == nil {
+		t.Errorf("expected an error deriving bounds of an invalid date")
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := "  created text,\n  created_earliest text,\n  created_latest text\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected %q in SQL\n%s", expected, buf.String())
+	}
+	buf.Reset()
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{`type="text" id="created"`, `placeholder="e.g. 1950~, 19XX, 1985-04/1990 or 2004-06/.."`, `title="An EDTF date: `} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %s in HTML\n%s", expected, buf.String())
+		}
+	}
+}
@@ -27,6 +27,7 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -39,6 +40,23 @@ type GenElementFunc func() *Element
 // received in the web form (value before converting to Go type).
 type ValidateFunc func(*Element, string) bool
 
+// NormalizeFunc is a function that converts a form value into its canonical form, e.g. an
+// ORCID in hyphenated form. It returns the value unchanged if it can't be normalized.
+type NormalizeFunc func(*Element, string) string
+
+// ValidateContextFunc is a validator that needs the application's data, e.g. to check
+// an identifier against a registry. It receives the Lookup passed in at validation time.
+// It returns false if the value is rejected and an error if the check couldn't be made.
+type ValidateContextFunc func(context.Context, Lookup, *Element, string) (bool, error)
+
+// DecodeFunc is a function that converts a validated form value into a native Go
+// value (e.g. time.Time for a date, float64 or int64 for a number).
+type DecodeFunc func(*Element, string) (interface{}, error)
+
+// ObjectValueFunc is a function that converts a value submitted as a JSON object
+// into a form value, returning false if the object isn't a value of the type.
+type ObjectValueFunc func(map[string]interface{}) (string, bool)
+
 
 // Element implementes the GitHub YAML issue template syntax for an input element.
 // The input element YAML is described at <https://docs.github.com/en/communities/using-templates-to-encourage-useful-issues-and-pull-requests/syntax-for-githubs-form-schema>
@@ -70,8 +88,36 @@ type Element struct {
 	// to generate appropriate validation code server side.
 	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
 
-	// Options holds a list of values and their labels used for HTML select elements in rendering their option child elements
-	Options []map[string]string `json:"optoins,omitempty" yaml:"options,omitempty"`
+	// Options holds a list of values and their labels used for HTML select elements in rendering their option child elements.
+	// They are also used by radio elements to render a radio group. Submitted values are checked against the option values.
+	Options []map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+
+	// Multiple indicates the element holds a list of values, e.g. a checkbox group, a multiple select
+	// or a repeated query parameter.
+	Multiple bool `json:"multiple,omitempty" yaml:"multiple,omitempty"`
+
+	// MinItems is the minimum number of values a multiple element must hold. An element
+	// with MinItems greater than zero is required.
+	MinItems int `json:"min_items,omitempty" yaml:"min_items,omitempty"`
+
+	// MaxItems is the maximum number of values a multiple element can hold. Zero means no limit.
+	MaxItems int `json:"max_items,omitempty" yaml:"max_items,omitempty"`
+
+	// RequiredIf holds a condition, written like a model rule's expression, that makes
+	// the element required, e.g. `resource_type == "thesis"`.
+	RequiredIf string `json:"required_if,omitempty" yaml:"required_if,omitempty"`
+
+	// ShowIf holds a condition, written like a model rule's expression, that controls
+	// if the element is shown in a web form. A hidden element isn't required.
+	ShowIf string `json:"show_if,omitempty" yaml:"show_if,omitempty"`
+
+	// Unique indicates no other record of the model can hold the element's value. It is
+	// checked by Model.ValidateContext using a Lookup.
+	Unique bool `json:"unique,omitempty" yaml:"unique,omitempty"`
+
+	// References holds the id of the model whose records the element's value refers to.
+	// The referenced record must exist. It is checked by Model.ValidateContext using a Lookup.
+	References string `json:"references,omitempty" yaml:"references,omitempty"`
 
 	// IsObjectId (i.e. is the identifier of the object) used by for the modeled data.
 	// It is used in calculating routes and templates where the object identifier is required.
@@ -85,6 +131,10 @@ type Element struct {
 	// the element's id.
 	Label string `json:"label,omitempty" yaml:"label,omitempty"`
 
+	// Model holds the id of a sub-model describing the element's value when it is an object (e.g. a creator
+	// with family and given names). The sub-model is found in the parent model's Models list.
+	Model string `json:"model,omitempty" yaml:"model,omitempty"`
+
 	//
 	// These fields are used by the modeler to manage the models and their elements
 	//
@@ -107,6 +157,53 @@ func NewElement(elementId string) (*Element, error) {
 	return element, nil
 }
 
+// IsRequired checks if the element must be present in submitted data. An element
+// is required if it is the object identifier, has a "required" attribute
+// not set to "false" or has min_items set.
+func (e *Element) IsRequired() bool {
+	if e.IsObjectId || e.MinItems > 0 {
+		return true
+	}
+	if val, ok := e.Attributes["required"]; ok {
+		return strings.ToLower(strings.TrimSpace(val)) != "false"
+	}
+	return false
+}
+
+// IsMultiple checks if the element holds a list of values. This is true
+// if Multiple is set, the element has a "multiple" attribute not set to "false"
+// or it is a checkbox with options (rendered as a group of checkboxes).
+func (e *Element) IsMultiple() bool {
+	if e.Multiple || (e.Type == "checkbox" && len(e.Options) > 0) {
+		return true
+	}
+	if val, ok := e.Attributes["multiple"]; ok {
+		return strings.ToLower(strings.TrimSpace(val)) != "false"
+	}
+	return false
+}
+
+// OptionValues returns the values of the element's options in order.
+func (e *Element) OptionValues() []string {
+	values := []string{}
+	for _, option := range e.Options {
+		if val, _, ok := getValAndLabel(option); ok {
+			values = append(values, val)
+		}
+	}
+	return values
+}
+
+// HasOption checks if a value is one of the element's option values.
+func (e *Element) HasOption(value string) bool {
+	for _, option := range e.Options {
+		if _, ok := option[value]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // HasChanged checks to see if the Element has been changed.
 func (e *Element) HasChanged() bool {
 	return e.isChanged
@@ -132,5 +229,15 @@ func (e *Element) Check(buf io.Writer) bool {
 		fmt.Fprintf(buf, "element, %q, missing type\n", e.Id)
 		ok = false
 	}
+	if err := checkProfile(e); err != nil {
+		fmt.Fprintf(buf, "element, %q, %s\n", e.Id, err)
+		ok = false
+	}
+	if e.Generator == "ark" {
+		if _, err := MintElementARK(e); err != nil {
+			fmt.Fprintf(buf, "element, %q, %s\n", e.Id, err)
+			ok = false
+		}
+	}
 	return ok
 }
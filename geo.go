@@ -0,0 +1,355 @@
+// geo.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Geographic values are WGS 84 latitudes and longitudes in decimal degrees. A
+// geo_point is written "lat,lon" and a geo_bbox "south,west,north,east", i.e. its
+// south west corner followed by its north east corner. Both can also be given in
+// Well-Known Text, i.e. "POINT(lon lat)" and "POLYGON((lon lat, ...))".
+
+var (
+	// ReGeoNumber matches a coordinate in decimal degrees
+	ReGeoNumber = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+	// ReWKTPoint matches a WKT point, e.g. "POINT(-118.1253 34.1377)"
+	ReWKTPoint = regexp.MustCompile(`^(?i)POINT\s*\(\s*(\S+)\s+(\S+)\s*\)$`)
+
+	// ReWKTPolygon matches a WKT polygon with a single ring, e.g. "POLYGON((-118.2 34.1, -118.1 34.1, -118.1 34.2, -118.2 34.2, -118.2 34.1))"
+	ReWKTPolygon = regexp.MustCompile(`^(?i)POLYGON\s*\(\s*\(([^()]+)\)\s*\)$`)
+)
+
+// GeoPoint holds a latitude and longitude in decimal degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat" yaml:"lat"`
+	Lon float64 `json:"lon" yaml:"lon"`
+}
+
+// String returns the point as "lat,lon".
+func (p GeoPoint) String() string {
+	return formatGeoNumber(p.Lat) + "," + formatGeoNumber(p.Lon)
+}
+
+// GeoBBox holds a bounding box as its south west and north east corners. A box
+// whose west longitude is greater than its east longitude crosses the antimeridian.
+type GeoBBox struct {
+	SouthWest GeoPoint `json:"sw" yaml:"sw"`
+	NorthEast GeoPoint `json:"ne" yaml:"ne"`
+}
+
+// String returns the box as "south,west,north,east".
+func (b GeoBBox) String() string {
+	return b.SouthWest.String() + "," + b.NorthEast.String()
+}
+
+// formatGeoNumber writes a coordinate without trailing zeros.
+func formatGeoNumber(f float64) string {
+	if f == 0 {
+		// NOTE: avoid writing -0
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseGeoNumber parses a coordinate, only plain decimal numbers are accepted.
+func parseGeoNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if !ReGeoNumber.MatchString(s) {
+		return 0, fmt.Errorf("%q is not a decimal number", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// newGeoPoint parses a latitude and longitude checking their range.
+func newGeoPoint(lat string, lon string) (*GeoPoint, error) {
+	p := new(GeoPoint)
+	var err error
+	if p.Lat, err = parseGeoNumber(lat); err != nil {
+		return nil, err
+	}
+	if p.Lon, err = parseGeoNumber(lon); err != nil {
+		return nil, err
+	}
+	if p.Lat < -90 || p.Lat > 90 {
+		return nil, fmt.Errorf("latitude %s is outside -90 to 90", formatGeoNumber(p.Lat))
+	}
+	if p.Lon < -180 || p.Lon > 180 {
+		return nil, fmt.Errorf("longitude %s is outside -180 to 180", formatGeoNumber(p.Lon))
+	}
+	return p, nil
+}
+
+// ParseGeoPoint parses a point written as "lat,lon" (e.g. "34.1377,-118.1253") or
+// as a WKT point (e.g. "POINT(-118.1253 34.1377)"). NOTE: WKT puts the longitude first.
+func ParseGeoPoint(s string) (*GeoPoint, error) {
+	s = strings.TrimSpace(s)
+	if m := ReWKTPoint.FindStringSubmatch(s); m != nil {
+		return newGeoPoint(m[2], m[1])
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected lat,lon or POINT(lon lat)")
+	}
+	return newGeoPoint(parts[0], parts[1])
+}
+
+// ParseGeoBBox parses a bounding box written as "south,west,north,east" (e.g.
+// "34.1,-118.2,34.2,-118.1") or as a WKT polygon whose ring is the box's corners.
+func ParseGeoBBox(s string) (*GeoBBox, error) {
+	s = strings.TrimSpace(s)
+	if m := ReWKTPolygon.FindStringSubmatch(s); m != nil {
+		return parseWKTBBox(m[1])
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("expected south,west,north,east or a WKT polygon")
+	}
+	sw, err := newGeoPoint(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	ne, err := newGeoPoint(parts[2], parts[3])
+	if err != nil {
+		return nil, err
+	}
+	if sw.Lat > ne.Lat {
+		return nil, fmt.Errorf("south %s is north of %s", formatGeoNumber(sw.Lat), formatGeoNumber(ne.Lat))
+	}
+	return &GeoBBox{SouthWest: *sw, NorthEast: *ne}, nil
+}
+
+// parseWKTBBox parses the ring of a WKT polygon. The ring must be closed and its
+// other four points must be the corners of the box.
+func parseWKTBBox(ring string) (*GeoBBox, error) {
+	points := []*GeoPoint{}
+	for _, pair := range strings.Split(ring, ",") {
+		xy := strings.Fields(pair)
+		if len(xy) != 2 {
+			return nil, fmt.Errorf("expected a polygon point to be lon lat, got %q", strings.TrimSpace(pair))
+		}
+		p, err := newGeoPoint(xy[1], xy[0])
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	if len(points) != 5 || *points[0] != *points[4] {
+		return nil, fmt.Errorf("expected a closed ring of four corners")
+	}
+	box := &GeoBBox{SouthWest: *points[0], NorthEast: *points[0]}
+	for _, p := range points[1:4] {
+		box.SouthWest.Lat, box.NorthEast.Lat = min(box.SouthWest.Lat, p.Lat), max(box.NorthEast.Lat, p.Lat)
+		box.SouthWest.Lon, box.NorthEast.Lon = min(box.SouthWest.Lon, p.Lon), max(box.NorthEast.Lon, p.Lon)
+	}
+	corners := map[GeoPoint]bool{}
+	for _, p := range points[0:4] {
+		if (p.Lat != box.SouthWest.Lat && p.Lat != box.NorthEast.Lat) || (p.Lon != box.SouthWest.Lon && p.Lon != box.NorthEast.Lon) {
+			return nil, fmt.Errorf("polygon is not a bounding box")
+		}
+		corners[*p] = true
+	}
+	if len(corners) != 4 {
+		return nil, fmt.Errorf("polygon is not a bounding box")
+	}
+	return box, nil
+}
+
+// geoCoordinate converts a number from a JSON object into a string.
+func geoCoordinate(v interface{}) (string, bool) {
+	switch v.(type) {
+	case float64, json.Number, int, int64:
+		return stringifyValue(v), true
+	}
+	return "", false
+}
+
+// GeoPointObjectValue converts a geo_point submitted as a JSON object, e.g.
+// {"lat": 34.1377, "lon": -118.1253}, into its form value "34.1377,-118.1253".
+func GeoPointObjectValue(obj map[string]interface{}) (string, bool) {
+	if len(obj) != 2 {
+		return "", false
+	}
+	lat, ok1 := geoCoordinate(obj["lat"])
+	lon, ok2 := geoCoordinate(obj["lon"])
+	if !ok1 || !ok2 {
+		return "", false
+	}
+	return lat + "," + lon, true
+}
+
+// GeoBBoxObjectValue converts a geo_bbox submitted as a JSON object, e.g.
+// {"sw": {"lat": 34.1, "lon": -118.2}, "ne": {"lat": 34.2, "lon": -118.1}},
+// into its form value "34.1,-118.2,34.2,-118.1".
+func GeoBBoxObjectValue(obj map[string]interface{}) (string, bool) {
+	if len(obj) != 2 {
+		return "", false
+	}
+	sw, ok1 := obj["sw"].(map[string]interface{})
+	ne, ok2 := obj["ne"].(map[string]interface{})
+	if !ok1 || !ok2 {
+		return "", false
+	}
+	swVal, ok1 := GeoPointObjectValue(sw)
+	neVal, ok2 := GeoPointObjectValue(ne)
+	if !ok1 || !ok2 {
+		return "", false
+	}
+	return swVal + "," + neVal, true
+}
+
+// GenerateGeoPoint sets up a geo_point element, rendered as latitude and longitude number inputs
+func GenerateGeoPoint() *Element {
+	return &Element{
+		Type: "geo_point",
+		Attributes: map[string]string{
+			"title": "Latitude and longitude in decimal degrees",
+		},
+	}
+}
+
+// ValidateGeoPoint checks a value is a point written as "lat,lon" or "POINT(lon lat)"
+// with the latitude within -90 to 90 and the longitude within -180 to 180.
+func ValidateGeoPoint(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, err := ParseGeoPoint(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG elem.Id %q, %s", elem.Id, err)
+		}
+		return false
+	}
+	return true
+}
+
+// DecodeGeoPoint converts a geo_point value into a GeoPoint.
+func DecodeGeoPoint(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	p, err := ParseGeoPoint(formValue)
+	if err != nil {
+		return nil, err
+	}
+	return *p, nil
+}
+
+// GenerateGeoBBox sets up a geo_bbox element, rendered as number inputs for its south west and north east corners
+func GenerateGeoBBox() *Element {
+	return &Element{
+		Type: "geo_bbox",
+		Attributes: map[string]string{
+			"title": "The latitude and longitude of the south west and north east corners in decimal degrees",
+		},
+	}
+}
+
+// ValidateGeoBBox checks a value is a bounding box written as "south,west,north,east"
+// or as a WKT polygon. The south latitude can't be north of the north latitude, a west
+// longitude greater than the east longitude crosses the antimeridian.
+func ValidateGeoBBox(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, err := ParseGeoBBox(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG elem.Id %q, %s", elem.Id, err)
+		}
+		return false
+	}
+	return true
+}
+
+// DecodeGeoBBox converts a geo_bbox value into a GeoBBox.
+func DecodeGeoBBox(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	b, err := ParseGeoBBox(formValue)
+	if err != nil {
+		return nil, err
+	}
+	return *b, nil
+}
+
+// geoPointColumns returns the derived columns holding a geo_point's latitude and longitude.
+func geoPointColumns() []*DerivedColumn {
+	column := func(suffix string, coordinate func(*GeoPoint) float64) *DerivedColumn {
+		return &DerivedColumn{
+			Suffix:   suffix,
+			SQLTypes: map[string]string{"sqlite": "real"},
+			Derive: func(elem *Element, formValue string) (interface{}, error) {
+				p, err := ParseGeoPoint(formValue)
+				if err != nil {
+					return nil, err
+				}
+				return coordinate(p), nil
+			},
+		}
+	}
+	return []*DerivedColumn{
+		column("_lat", func(p *GeoPoint) float64 { return p.Lat }),
+		column("_lon", func(p *GeoPoint) float64 { return p.Lon }),
+	}
+}
+
+// geoBBoxColumns returns the derived columns holding a geo_bbox's edges.
+func geoBBoxColumns() []*DerivedColumn {
+	column := func(suffix string, edge func(*GeoBBox) float64) *DerivedColumn {
+		return &DerivedColumn{
+			Suffix:   suffix,
+			SQLTypes: map[string]string{"sqlite": "real"},
+			Derive: func(elem *Element, formValue string) (interface{}, error) {
+				b, err := ParseGeoBBox(formValue)
+				if err != nil {
+					return nil, err
+				}
+				return edge(b), nil
+			},
+		}
+	}
+	return []*DerivedColumn{
+		column("_south", func(b *GeoBBox) float64 { return b.SouthWest.Lat }),
+		column("_west", func(b *GeoBBox) float64 { return b.SouthWest.Lon }),
+		column("_north", func(b *GeoBBox) float64 { return b.NorthEast.Lat }),
+		column("_east", func(b *GeoBBox) float64 { return b.NorthEast.Lon }),
+	}
+}
@@ -0,0 +1,196 @@
+// geo_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestGeoPoint tests parsing and normalizing geo_point values.
+func TestGeoPoint(t *testing.T) {
+	elem := &Element{Id: "location", Type: "geo_point"}
+	expected := map[string]string{
+		"34.1377,-118.1253":           "34.1377,-118.1253",
+		" 34.1377 , -118.1253 ":       "34.1377,-118.1253",
+		"POINT(-118.1253 34.1377)":    "34.1377,-118.1253",
+		"point ( -118.1253 34.1377 )": "34.1377,-118.1253",
+		"-90,180":                     "-90,180",
+		"+45.50,-0":                   "45.5,0",
+	}
+	for val, e := range expected {
+		if !ValidateGeoPoint(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+		if got := NormalizeGeoPoint(elem, val); got != e {
+			t.Errorf("expected %q to normalize to %q, got %q", val, e, got)
+		}
+	}
+	for _, val := range []string{"34.1377", "91,0", "0,180.5", "34.1377,-118.1253,0", "NaN,0", "Inf,0", "1e2,0", "0x1p-2,0", "POINT(34.1377)", "POINT(-118.1253 91)"} {
+		if ValidateGeoPoint(elem, val) {
+			t.Errorf("expected %q to fail validation", val)
+		}
+	}
+}
+
+// TestGeoBBox tests parsing and normalizing geo_bbox values.
+func TestGeoBBox(t *testing.T) {
+	elem := &Element{Id: "extent", Type: "geo_bbox"}
+	expected := map[string]string{
+		"34.1,-118.2,34.2,-118.1": "34.1,-118.2,34.2,-118.1",
+		"POLYGON((-118.2 34.1, -118.1 34.1, -118.1 34.2, -118.2 34.2, -118.2 34.1))": "34.1,-118.2,34.2,-118.1",
+		"POLYGON((-118.1 34.2, -118.2 34.2, -118.2 34.1, -118.1 34.1, -118.1 34.2))": "34.1,-118.2,34.2,-118.1",
+		// Crosses the antimeridian
+		"-20,170,-10,-170": "-20,170,-10,-170",
+	}
+	for val, e := range expected {
+		if !ValidateGeoBBox(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+		if got := NormalizeGeoBBox(elem, val); got != e {
+			t.Errorf("expected %q to normalize to %q, got %q", val, e, got)
+		}
+	}
+	for _, val := range []string{"34.1,-118.2", "34.2,-118.2,34.1,-118.1", "34.1,-118.2,34.2,-181",
+		"POLYGON((-118.2 34.1, -118.1 34.1, -118.1 34.2, -118.2 34.2))",
+		"POLYGON((-118.2 34.1, -118.1 34.1, -118.15 34.2, -118.2 34.2, -118.2 34.1))",
+		"POLYGON((-118.2 34.1, -118.1 34.1, -118.2 34.1, -118.1 34.1, -118.2 34.1))"} {
+		if ValidateGeoBBox(elem, val) {
+			t.Errorf("expected %q to fail validation", val)
+		}
+	}
+}
+
+// TestGeoElements tests validating, deriving, binding and rendering geo_point and geo_bbox elements.
+func TestGeoElements(t *testing.T) {
+	src := []byte(`id: site
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: location
+    type: geo_point
+    label: Location
+  - id: extent
+    type: geo_bbox
+    label: Extent
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// A JSON submission can use the object form of the TypeScript types
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{"id": "one", "location": {"lat": 34.1377, "lon": -118.1253}, "extent": {"sw": {"lat": 34.1, "lon": -118.2}, "ne": {"lat": 34.2, "lon": -118.1}}}`), &data); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if result := model.ValidateMapInterfaceReport(data); !result.OK() {
+		t.Errorf("expected object values to validate, %s", result)
+	}
+	// The hidden input is empty until every coordinate is given
+	if result := model.ValidateReport(map[string]string{"id": "one", "location": "", "extent": ""}); !result.OK() {
+		t.Errorf("expected empty optional geo values to validate, %s", result)
+	}
+	data["location"] = map[string]interface{}{"lat": 91.0, "lon": 0.0}
+	if result := model.ValidateMapInterfaceReport(data); result.OK() {
+		t.Errorf("expected latitude 91 to fail validation")
+	}
+	data["id"] = map[string]interface{}{"lat": 34.1377, "lon": -118.1253}
+	data["location"] = "34.1377,-118.1253"
+	if result := model.ValidateMapInterfaceReport(data); result.OK() {
+		t.Errorf("expected an object value for a text element to fail validation")
+	}
+
+	columns, err := model.DeriveColumns(map[string]interface{}{"location": "POINT(-118.1253 34.1377)", "extent": "34.1,-118.2,34.2,-118.1"})
+	if err != nil {
+		t.Error(err)
+	}
+	for k, v := range map[string]float64{"location_lat": 34.1377, "location_lon": -118.1253, "extent_south": 34.1, "extent_west": -118.2, "extent_north": 34.2, "extent_east": -118.1} {
+		if columns[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, columns[k])
+		}
+	}
+
+	record := struct {
+		Id       string   `model:"id"`
+		Location GeoPoint `model:"location"`
+		Extent   GeoBBox  `model:"extent"`
+	}{}
+	if err := model.CheckStruct(&record); err != nil {
+		t.Error(err)
+	}
+	if err := model.Bind(map[string]string{"id": "one", "location": "34.1377,-118.1253", "extent": "34.1,-118.2,34.2,-118.1"}, &record); err != nil {
+		t.Error(err)
+	}
+	if record.Location.Lat != 34.1377 || record.Location.Lon != -118.1253 || record.Extent.NorthEast.Lat != 34.2 {
+		t.Errorf("expected location and extent to be bound, got %+v", record)
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := "  location text,\n  location_lat real,\n  location_lon real,\n  extent text,\n  extent_south real,\n  extent_west real,\n  extent_north real,\n  extent_east real\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected %q in SQL\n%s", expected, buf.String())
+	}
+	buf.Reset()
+	if err := ModelToTypeScriptClass(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{"location: {lat:number, lon:number};", "location: {lat:number, lon:number} = {lat: 0, lon: 0};",
+		"extent: {sw:{lat:number, lon:number}, ne:{lat:number, lon:number}};"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in TypeScript\n%s", expected, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{`<fieldset class="site-location" id="location" data-geo="geo_point">`,
+		`<input type="hidden" name="location" value="">`,
+		`<input class="site-location" type="number" id="location_lat" min="-90" max="90" step="any">`,
+		`<input class="site-location" type="number" id="location_lon" min="-180" max="180" step="any">`,
+		`<input class="site-extent" type="number" id="extent_east" min="-180" max="180" step="any">`,
+		`fieldset[data-geo]`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %s in HTML\n%s", expected, buf.String())
+		}
+	}
+}
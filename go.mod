@@ -2,13 +2,13 @@ module github.com/caltechlibrary/models
 
 go 1.23.1
 
-require gopkg.in/yaml.v3 v3.0.1
-
 require (
-	github.com/Dancapistan/gobase32 v0.0.0-20131203192308-203acd9f6b68 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/nyaruka/phonenumbers v1.4.0 // indirect
-	github.com/pkg/fileutils v0.0.0-20181114200823-d734b7f202ba // indirect
-	golang.org/x/text v0.15.0 // indirect
-	google.golang.org/protobuf v1.34.1 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/nyaruka/phonenumbers v1.4.0
+	github.com/pkg/fileutils v0.0.0-20181114200823-d734b7f202ba
+	golang.org/x/net v0.25.0
+	golang.org/x/text v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require google.golang.org/protobuf v1.34.1 // indirect
@@ -28,6 +28,7 @@ package models
 
 import (
 	"fmt"
+	"html"
 	"io"
 	"strings"
 )
@@ -51,14 +52,18 @@ func ModelToHTML(out io.Writer, model *Model) error {
 		case "required":
 			fmt.Fprintf(out, " required")
 		default:
-			fmt.Fprintf(out, " %s=%q", k, v)
+			fmt.Fprintf(out, " %s=\"%s\"", k, html.EscapeString(v))
 		}
 	}
 	cssBaseClass := strings.ReplaceAll(strings.ToLower(model.Id), " ", "_")
 	fmt.Fprintf(out, ">\n")
 	for _, elem := range model.Elements {
-		ElementToHTML(out, cssBaseClass, elem)
+		elementToHTML(out, model, cssBaseClass, elem)
 	}
+	if err := conditionsToScript(out, model); err != nil {
+		return err
+	}
+	geoToScript(out, model)
 	if !model.HasElementType("submit") {
 		cssName := fmt.Sprintf("%s-submit", cssBaseClass)
 		fmt.Fprintf(out, `  <div class=%q><input class=%q type="submit" value="submit"> <input class=%q type="reset" value="cancel"></div>`,
@@ -71,10 +76,64 @@ func ModelToHTML(out io.Writer, model *Model) error {
 }
 
 // ElementToHTML renders an individual element as HTML, includes label as well as input element.
+// The element's type is mapped to an HTML input type using the default type definitions.
 func ElementToHTML(out io.Writer, cssBaseClass string, elem *Element) error {
+	return elementToHTML(out, nil, cssBaseClass, elem)
+}
+
+// htmlAttributes returns the attributes to render for an element. An element whose type
+// is rendered as another HTML input type (e.g. an orcid or doi is a text input) also gets
+// the pattern, placeholder and help text (title) set up by its type's generator unless
+// it sets its own.
+func htmlAttributes(model *Model, elem *Element) map[string]string {
+	if model.resolveType(elem.Type).HTMLType == elem.Type {
+		return elem.Attributes
+	}
+	generated, ok := model.GenElementType(elem.Type)
+	if !ok || generated == nil {
+		return elem.Attributes
+	}
+	defaults := map[string]string{}
+	for k, v := range generated.Attributes {
+		defaults[k] = v
+	}
+	if generated.Pattern != "" {
+		defaults["pattern"] = generated.Pattern
+	}
+	if elem.Pattern != "" {
+		defaults["pattern"] = elem.Pattern
+	}
+	attributes := map[string]string{}
+	for k, v := range elem.Attributes {
+		attributes[k] = v
+	}
+	for _, k := range []string{"pattern", "placeholder", "title"} {
+		if _, ok := attributes[k]; !ok && defaults[k] != "" {
+			attributes[k] = defaults[k]
+		}
+	}
+	return attributes
+}
+
+// elementToHTML renders an element mapping its type to an HTML input type using
+// the model's type definitions.
+func elementToHTML(out io.Writer, model *Model, cssBaseClass string, elem *Element) error {
 	cssClass := fmt.Sprintf("%s-%s", cssBaseClass, strings.ToLower(elem.Id))
+	inputType := strings.ToLower(model.resolveType(elem.Type).HTMLType)
+	switch inputType {
+	case "select":
+		return selectToHTML(out, cssClass, elem)
+	case "radio", "checkbox":
+		if len(elem.Options) > 0 {
+			return optionGroupToHTML(out, cssClass, inputType, elem)
+		}
+	case "geo_point", "geo_bbox":
+		return geoToHTML(out, cssClass, inputType, elem, htmlAttributes(model, elem))
+	case "language", "country", "script", "currency":
+		return codeListToHTML(out, model, cssClass, inputType, elem)
+	}
 	fmt.Fprintf(out, "  <div class=%q>", cssClass)
-	switch strings.ToLower(elem.Type) {
+	switch inputType {
 	case "textarea":
 		if elem.Label != "" {
 			if name, ok := elem.Attributes["name"]; ok {
@@ -90,28 +149,30 @@ func ElementToHTML(out io.Writer, cssBaseClass string, elem *Element) error {
 	default:
 		if elem.Label != "" {
 			if name, ok := elem.Attributes["name"]; ok {
-				fmt.Fprintf(out, "<label class=%q set=%q>%s</label> <input class=%q type=%q", cssClass, name, elem.Label, cssClass, elem.Type)
+				fmt.Fprintf(out, "<label class=%q set=%q>%s</label> <input class=%q type=%q", cssClass, name, elem.Label, cssClass, inputType)
 			} else {
-				fmt.Fprintf(out, "<label class=%q set=%q>%s</label> <input class=%q name=%q type=%q", cssClass, elem.Id, elem.Label, cssClass, elem.Id, elem.Type)
+				fmt.Fprintf(out, "<label class=%q set=%q>%s</label> <input class=%q name=%q type=%q", cssClass, elem.Id, elem.Label, cssClass, elem.Id, inputType)
 			}
 		} else {
-			fmt.Fprintf(out, "<input class=%q type=%q", cssClass, elem.Type)
+			fmt.Fprintf(out, "<input class=%q type=%q", cssClass, inputType)
 		}
 	}
 	if elem.Id != "" {
 		fmt.Fprintf(out, " id=%q", elem.Id)
 	}
-	for k, v := range elem.Attributes {
+	for k, v := range htmlAttributes(model, elem) {
 		switch k {
 		case "checked":
 			fmt.Fprintf(out, " checked")
 		case "required":
 			fmt.Fprintf(out, " required")
+		case "multiple":
+			fmt.Fprintf(out, " multiple")
 		default:
-			fmt.Fprintf(out, " %s=%q", k, v)
+			fmt.Fprintf(out, " %s=\"%s\"", k, html.EscapeString(v))
 		}
 	}
-	switch strings.ToLower(elem.Type) {
+	switch inputType {
 	case "button":
 		fmt.Fprintf(out, " >%s</button>", elem.Label)
 	case "textarea":
@@ -122,3 +183,341 @@ func ElementToHTML(out io.Writer, cssBaseClass string, elem *Element) error {
 	fmt.Fprintf(out, "</div>\n")
 	return nil
 }
+
+// writeAttributes renders the attributes of an element in sorted order, skipping
+// those listed in skip. Boolean attributes are rendered without a value.
+func writeAttributes(out io.Writer, attributes map[string]string, skip ...string) {
+	for _, k := range getAttributeIds(attributes) {
+		if inList(skip, k) {
+			continue
+		}
+		v := attributes[k]
+		switch k {
+		case "checked", "required", "multiple", "disabled", "readonly":
+			if strings.ToLower(v) != "false" {
+				fmt.Fprintf(out, " %s", k)
+			}
+		default:
+			fmt.Fprintf(out, " %s=\"%s\"", k, html.EscapeString(v))
+		}
+	}
+}
+
+// selectToHTML renders a select element with its options. If the element is
+// multiple the select allows more than one choice.
+func selectToHTML(out io.Writer, cssClass string, elem *Element) error {
+	name := elem.Id
+	if val, ok := elem.Attributes["name"]; ok {
+		name = val
+	}
+	fmt.Fprintf(out, "  <div class=%q>", cssClass)
+	if elem.Label != "" {
+		fmt.Fprintf(out, "<label class=%q set=%q>%s</label> ", cssClass, name, elem.Label)
+	}
+	fmt.Fprintf(out, "<select class=%q name=%q", cssClass, name)
+	if elem.Id != "" {
+		fmt.Fprintf(out, " id=%q", elem.Id)
+	}
+	writeAttributes(out, elem.Attributes, "name", "value")
+	if _, ok := elem.Attributes["multiple"]; !ok && elem.Multiple {
+		fmt.Fprintf(out, " multiple")
+	}
+	fmt.Fprintf(out, ">\n")
+	selected := elem.Attributes["value"]
+	for _, option := range elem.Options {
+		val, label, ok := getValAndLabel(option)
+		if !ok {
+			continue
+		}
+		if val == selected && selected != "" {
+			fmt.Fprintf(out, "    <option value=\"%s\" selected>%s</option>\n", html.EscapeString(val), html.EscapeString(label))
+		} else {
+			fmt.Fprintf(out, "    <option value=\"%s\">%s</option>\n", html.EscapeString(val), html.EscapeString(label))
+		}
+	}
+	fmt.Fprintf(out, "  </select></div>\n")
+	return nil
+}
+
+// optionGroupToHTML renders a fieldset holding a radio or checkbox input for each of the
+// element's options. A checkbox group submits each checked option under the same name.
+func optionGroupToHTML(out io.Writer, cssClass string, inputType string, elem *Element) error {
+	name := elem.Id
+	if val, ok := elem.Attributes["name"]; ok {
+		name = val
+	}
+	fmt.Fprintf(out, "  <div class=%q><fieldset class=%q id=%q>", cssClass, cssClass, elem.Id)
+	if elem.Label != "" {
+		fmt.Fprintf(out, "<legend>%s</legend>", elem.Label)
+	}
+	fmt.Fprintf(out, "\n")
+	checked := elem.Attributes["value"]
+	for i, option := range elem.Options {
+		val, label, ok := getValAndLabel(option)
+		if !ok {
+			continue
+		}
+		optionId := fmt.Sprintf("%s_%d", elem.Id, i)
+		fmt.Fprintf(out, "    <input class=%q type=%q name=%q id=%q value=\"%s\"", cssClass, inputType, name, optionId, html.EscapeString(val))
+		writeAttributes(out, elem.Attributes, "name", "value", "checked", "multiple")
+		if val == checked && checked != "" {
+			fmt.Fprintf(out, " checked")
+		}
+		fmt.Fprintf(out, "> <label for=%q>%s</label>\n", optionId, html.EscapeString(label))
+	}
+	fmt.Fprintf(out, "  </fieldset></div>\n")
+	return nil
+}
+
+// codeListToHTML renders a language element as a text input with a datalist suggesting
+// languages and a country, script or currency element as a select. The options are
+// named in the language of the element's or form's "lang" attribute (see CodeOptions).
+// If the element has options they are listed instead, an option without a label is
+// given the code's name.
+func codeListToHTML(out io.Writer, model *Model, cssClass string, inputType string, elem *Element) error {
+	lang := elem.Attributes["lang"]
+	if lang == "" && model != nil {
+		lang = model.Attributes["lang"]
+	}
+	options := CodeOptions(inputType, lang)
+	if len(elem.Options) > 0 {
+		names := map[string]string{}
+		for _, option := range options {
+			val, label, _ := getValAndLabel(option)
+			names[val] = label
+		}
+		options = []map[string]string{}
+		for _, option := range elem.Options {
+			val, label, ok := getValAndLabel(option)
+			if !ok {
+				continue
+			}
+			if (label == "" || label == val) && names[val] != "" {
+				label = names[val]
+			}
+			options = append(options, map[string]string{val: label})
+		}
+	}
+	if inputType != "language" {
+		listElem := *elem
+		listElem.Options = options
+		if !elem.IsMultiple() {
+			// NOTE: an empty first option keeps the select from defaulting to the first code
+			listElem.Options = append([]map[string]string{{"": ""}}, options...)
+		}
+		return selectToHTML(out, cssClass, &listElem)
+	}
+	attributes := map[string]string{}
+	if generated, ok := model.GenElementType(elem.Type); ok && generated != nil {
+		for _, k := range []string{"placeholder", "title"} {
+			if v, ok := generated.Attributes[k]; ok {
+				attributes[k] = v
+			}
+		}
+	}
+	for k, v := range elem.Attributes {
+		attributes[k] = v
+	}
+	name := elem.Id
+	if val, ok := attributes["name"]; ok {
+		name = val
+	}
+	listId := elem.Id + "_list"
+	fmt.Fprintf(out, "  <div class=%q>", cssClass)
+	if elem.Label != "" {
+		fmt.Fprintf(out, "<label class=%q set=%q>%s</label> ", cssClass, name, elem.Label)
+	}
+	fmt.Fprintf(out, "<input class=%q name=%q type=\"text\" id=%q list=%q", cssClass, name, elem.Id, listId)
+	writeAttributes(out, attributes, "name", "list")
+	fmt.Fprintf(out, "><datalist id=%q>\n", listId)
+	for _, option := range options {
+		val, label, ok := getValAndLabel(option)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(out, "    <option value=\"%s\">%s</option>\n", html.EscapeString(val), html.EscapeString(label))
+	}
+	fmt.Fprintf(out, "  </datalist></div>\n")
+	return nil
+}
+
+// geoInputs lists the number inputs of a geo_point and geo_bbox as id suffix, label,
+// minimum and maximum.
+var geoInputs = map[string][][4]string{
+	"geo_point": {{"_lat", "Latitude", "-90", "90"}, {"_lon", "Longitude", "-180", "180"}},
+	"geo_bbox": {{"_south", "South", "-90", "90"}, {"_west", "West", "-180", "180"},
+		{"_north", "North", "-90", "90"}, {"_east", "East", "-180", "180"}},
+}
+
+// geoToHTML renders a fieldset holding a number input for each coordinate of a geo_point
+// or geo_bbox. The number inputs are linked to a hidden input holding the element's value,
+// e.g. "34.1377,-118.1253", by the script written by geoToScript.
+func geoToHTML(out io.Writer, cssClass string, inputType string, elem *Element, attributes map[string]string) error {
+	name := elem.Id
+	if val, ok := attributes["name"]; ok {
+		name = val
+	}
+	fmt.Fprintf(out, "  <div class=%q><fieldset class=%q id=%q data-geo=%q", cssClass, cssClass, elem.Id, inputType)
+	if title, ok := attributes["title"]; ok {
+		fmt.Fprintf(out, " title=\"%s\"", html.EscapeString(title))
+	}
+	fmt.Fprintf(out, ">")
+	if elem.Label != "" {
+		fmt.Fprintf(out, "<legend>%s</legend>", elem.Label)
+	}
+	fmt.Fprintf(out, "\n")
+	// NOTE: a value is split into its coordinates, e.g. "34.1377,-118.1253"
+	value := attributes["value"]
+	var parts []string
+	switch inputType {
+	case "geo_point":
+		if p, err := ParseGeoPoint(value); err == nil {
+			value, parts = p.String(), strings.Split(p.String(), ",")
+		}
+	case "geo_bbox":
+		if b, err := ParseGeoBBox(value); err == nil {
+			value, parts = b.String(), strings.Split(b.String(), ",")
+		}
+	}
+	fmt.Fprintf(out, "    <input type=\"hidden\" name=%q value=\"%s\">\n", name, html.EscapeString(value))
+	for i, input := range geoInputs[inputType] {
+		inputId := elem.Id + input[0]
+		fmt.Fprintf(out, "    <label for=%q>%s</label> <input class=%q type=\"number\" id=%q min=%q max=%q step=\"any\"",
+			inputId, input[1], cssClass, inputId, input[2], input[3])
+		if i < len(parts) {
+			fmt.Fprintf(out, " value=%q", parts[i])
+		}
+		writeAttributes(out, attributes, "name", "value", "title", "pattern", "placeholder", "min", "max", "step", "multiple")
+		fmt.Fprintf(out, ">\n")
+	}
+	fmt.Fprintf(out, "  </fieldset></div>\n")
+	return nil
+}
+
+// geoToScript writes a script keeping the hidden input of each geo_point and geo_bbox
+// in step with its number inputs. The value is empty until every coordinate is given.
+// Nothing is written if the model has no geo elements.
+func geoToScript(out io.Writer, model *Model) {
+	hasGeo := false
+	for _, elem := range model.Elements {
+		if _, ok := geoInputs[strings.ToLower(model.resolveType(elem.Type).HTMLType)]; ok {
+			hasGeo = true
+			break
+		}
+	}
+	if !hasGeo {
+		return
+	}
+	fmt.Fprint(out, `  <script>
+  (function () {
+    const form = document.currentScript.closest("form");
+    for (const fieldset of form.querySelectorAll("fieldset[data-geo]")) {
+      const value = fieldset.querySelector('input[type="hidden"]');
+      const inputs = Array.from(fieldset.querySelectorAll('input[type="number"]'));
+      const update = () => {
+        const coordinates = inputs.map((input) => input.value.trim());
+        value.value = coordinates.every((c) => c !== "") ? coordinates.join(",") : "";
+      };
+      for (const input of inputs) input.addEventListener("input", update);
+      form.addEventListener("reset", () => setTimeout(update));
+    }
+  })();
+  </script>
+`)
+}
+
+// conditionsScriptHelpers are the JavaScript functions used to evaluate the
+// show_if and required_if conditions in the browser. v() returns an element's
+// value (a list for checkboxes and multiple selects), t() tests if a value is
+// true, n() counts values and c() compares values as numbers or strings.
+const conditionsScriptHelpers = `    const v = (name) => {
+      const vals = [];
+      let list = false;
+      for (const el of form.querySelectorAll('[name="' + name + '"]')) {
+        if (el.type === "checkbox" || el.type === "radio") {
+          list = list || el.type === "checkbox";
+          if (el.checked) vals.push(el.value);
+        } else if (el.multiple) {
+          list = true;
+          for (const option of el.selectedOptions) vals.push(option.value);
+        } else {
+          vals.push(el.value);
+        }
+      }
+      return list ? vals : (vals[0] || "");
+    };
+    const t = (x) => Array.isArray(x) ? x.length > 0 : (typeof x === "string" ? x !== "" && x.toLowerCase() !== "false" : !!x);
+    const n = (x) => Array.isArray(x) ? x.length : (x === "" ? 0 : 1);
+    const c = (op, l, r) => {
+      l = String(l);
+      r = String(r);
+      let d = l < r ? -1 : (l > r ? 1 : 0);
+      if (l.trim() !== "" && r.trim() !== "" && !isNaN(l) && !isNaN(r)) d = Number(l) - Number(r);
+      switch (op) {
+        case "==": return d === 0;
+        case "!=": return d !== 0;
+        case "<": return d < 0;
+        case "<=": return d <= 0;
+        case ">": return d > 0;
+        case ">=": return d >= 0;
+      }
+      return false;
+    };
+`
+
+// conditionsToScript writes a script showing, hiding and requiring elements based
+// on their show_if and required_if conditions. A hidden element is disabled so
+// it isn't submitted. Nothing is written if the model has no conditions.
+func conditionsToScript(out io.Writer, model *Model) error {
+	conditions := []string{}
+	for _, elem := range model.Elements {
+		if elem.ShowIf == "" && elem.RequiredIf == "" {
+			continue
+		}
+		name := elem.Id
+		if val, ok := elem.Attributes["name"]; ok {
+			name = val
+		}
+		parts := []string{fmt.Sprintf("id: %q", elem.Id), fmt.Sprintf("name: %q", name)}
+		if elem.ShowIf != "" {
+			node, _, err := parseRule(elem.ShowIf)
+			if err != nil {
+				return fmt.Errorf("%s show_if %q, %s", elem.Id, elem.ShowIf, err)
+			}
+			parts = append(parts, fmt.Sprintf("show: () => %s", node.js()))
+		}
+		if elem.RequiredIf != "" && !elem.IsRequired() {
+			node, _, err := parseRule(elem.RequiredIf)
+			if err != nil {
+				return fmt.Errorf("%s required_if %q, %s", elem.Id, elem.RequiredIf, err)
+			}
+			parts = append(parts, fmt.Sprintf("required: () => %s", node.js()))
+		}
+		conditions = append(conditions, fmt.Sprintf("      { %s },\n", strings.Join(parts, ", ")))
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "  <script>\n  (function () {\n    const form = document.currentScript.closest(\"form\");\n")
+	fmt.Fprint(out, conditionsScriptHelpers)
+	fmt.Fprintf(out, "    const conditions = [\n%s    ];\n", strings.Join(conditions, ""))
+	fmt.Fprint(out, `    const update = () => {
+      for (const cond of conditions) {
+        const shown = cond.show ? t(cond.show()) : true;
+        const el = document.getElementById(cond.id);
+        const div = el ? el.closest("div") : null;
+        if (div) div.hidden = !shown;
+        for (const control of form.querySelectorAll('[name="' + cond.name + '"]')) {
+          control.disabled = !shown;
+          if (cond.required && control.type !== "checkbox") control.required = shown && t(cond.required());
+        }
+      }
+    };
+    form.addEventListener("input", update);
+    form.addEventListener("change", update);
+    update();
+  })();
+  </script>
+`)
+	return nil
+}
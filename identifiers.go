@@ -0,0 +1,322 @@
+// identifiers.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DOIPattern matches a DOI in its bare (10.1000/xyz), "doi:" or https://doi.org URL form.
+	DOIPattern = `((https?://(dx\.)?doi\.org/)|([dD][oO][iI]:))?10\.[0-9]{4,}(\.[0-9]+)*/\S+`
+)
+
+var (
+	// ReDOIPrefix matches the prefix of a DOI, "10." followed by the registrant code
+	ReDOIPrefix *regexp.Regexp
+
+	// doiPrefixes are the forms a DOI can be written in ahead of the prefix, they are matched ignoring case.
+	doiPrefixes = []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"}
+)
+
+// parseDOI splits a DOI into its prefix and suffix. It accepts the bare form
+// (10.1000/xyz123), the "doi:" form and the doi.org URL form. The suffix of a URL
+// is unescaped.
+func parseDOI(formValue string) (string, string, error) {
+	val := strings.TrimSpace(formValue)
+	for _, prefix := range doiPrefixes {
+		if len(val) >= len(prefix) && strings.EqualFold(val[0:len(prefix)], prefix) {
+			val = val[len(prefix):]
+			if strings.HasSuffix(prefix, "/") {
+				s, err := url.PathUnescape(val)
+				if err != nil {
+					return "", "", err
+				}
+				val = s
+			}
+			break
+		}
+	}
+	prefix, suffix, ok := strings.Cut(val, "/")
+	if !ok {
+		return "", "", fmt.Errorf("%q is missing the \"/\" between prefix and suffix", formValue)
+	}
+	if !ReDOIPrefix.MatchString(prefix) {
+		return "", "", fmt.Errorf("%q is not a DOI prefix", prefix)
+	}
+	if suffix == "" {
+		return "", "", fmt.Errorf("%q is missing a suffix", formValue)
+	}
+	for _, r := range suffix {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return "", "", fmt.Errorf("%q has a suffix containing spaces or control characters", formValue)
+		}
+	}
+	return prefix, suffix, nil
+}
+
+// GenerateDOI sets up an HTML input type text using a pattern for DOI
+func GenerateDOI() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     DOIPattern,
+			"placeholder": "e.g. 10.1000/182",
+		},
+	}
+}
+
+// ValidateDOI checks the syntax of a DOI's prefix and suffix. The DOI can be given
+// in its bare, "doi:" or https://doi.org URL form.
+func ValidateDOI(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, _, err := parseDOI(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+const (
+	// PMIDPattern matches a PubMed id, on its own, with a "PMID:" label or as a PubMed URL.
+	PMIDPattern = `(https?://pubmed\.ncbi\.nlm\.nih\.gov/[1-9][0-9]{0,8}/?)|([pP][mM][iI][dD]: ?)?[1-9][0-9]{0,8}`
+
+	// PMCIDPattern matches a PubMed Central id with an optional version, on its own or as a PMC article URL.
+	PMCIDPattern = `(https?://(www\.ncbi\.nlm\.nih\.gov/pmc|pmc\.ncbi\.nlm\.nih\.gov)/articles/[pP][mM][cC][0-9]{1,9}(\.[0-9]+)?/?)|[pP][mM][cC][0-9]{1,9}(\.[0-9]+)?`
+
+	// ArXivPattern matches an arXiv id in the new (2101.00001v2) or old (hep-th/9901001) style with an optional
+	// version. It can have an "arXiv:" label or be an arxiv.org abstract or PDF URL.
+	ArXivPattern = `((https?://(www\.)?arxiv\.org/(abs|pdf)/)|([aA][rR][xX][iI][vV]:))?([0-9]{4}\.[0-9]{4,5}|[a-z]+(-[a-z]+)*(\.[A-Z]{2})?/[0-9]{7})(v[1-9][0-9]*)?(\.pdf)?`
+)
+
+var (
+	// RePMID matches a PubMed id (see PMIDPattern)
+	RePMID *regexp.Regexp
+
+	// RePMCID matches a PubMed Central id (see PMCIDPattern)
+	RePMCID *regexp.Regexp
+
+	// ReArXiv matches an arXiv id (see ArXivPattern)
+	ReArXiv *regexp.Regexp
+
+	// arXivArchives holds the archives used by old style arXiv ids, e.g. "hep-th" in hep-th/9901001.
+	arXivArchives = []string{
+		"acc-phys", "adap-org", "alg-geom", "ao-sci", "astro-ph", "atom-ph", "bayes-an", "chao-dyn",
+		"chem-ph", "cmp-lg", "comp-gas", "cond-mat", "cs", "dg-ga", "funct-an", "gr-qc", "hep-ex",
+		"hep-lat", "hep-ph", "hep-th", "math", "math-ph", "mtrl-th", "nlin", "nucl-ex", "nucl-th",
+		"patt-sol", "physics", "plasm-ph", "q-alg", "q-bio", "quant-ph", "solv-int", "supr-con",
+	}
+)
+
+// trimIdentifierPrefix removes the first of the prefixes (matched ignoring case) that starts val.
+func trimIdentifierPrefix(val string, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		if len(val) >= len(prefix) && strings.EqualFold(val[0:len(prefix)], prefix) {
+			return val[len(prefix):]
+		}
+	}
+	return val
+}
+
+// parsePMID returns the PubMed id without its label or URL.
+func parsePMID(formValue string) (string, error) {
+	val := strings.TrimSpace(formValue)
+	if !RePMID.MatchString(val) {
+		return "", fmt.Errorf("%q is not a PMID", formValue)
+	}
+	val = trimIdentifierPrefix(val, "https://pubmed.ncbi.nlm.nih.gov/", "http://pubmed.ncbi.nlm.nih.gov/", "pmid:")
+	return strings.TrimSpace(strings.TrimSuffix(val, "/")), nil
+}
+
+// ParsePMCID splits a PubMed Central id into the id (e.g. "PMC3531190") and its
+// version (e.g. "2" for "PMC3531190.2"), the version is empty if not given. The id
+// can be a PMC article URL.
+func ParsePMCID(formValue string) (string, string, error) {
+	val := strings.TrimSpace(formValue)
+	if !RePMCID.MatchString(val) {
+		return "", "", fmt.Errorf("%q is not a PMCID", formValue)
+	}
+	val = trimIdentifierPrefix(val, "https://www.ncbi.nlm.nih.gov/pmc/articles/", "http://www.ncbi.nlm.nih.gov/pmc/articles/",
+		"https://pmc.ncbi.nlm.nih.gov/articles/", "http://pmc.ncbi.nlm.nih.gov/articles/")
+	val = strings.TrimSuffix(val, "/")
+	id, version, _ := strings.Cut(val, ".")
+	return "PMC" + id[3:], version, nil
+}
+
+// ParseArXiv splits an arXiv id into the id (e.g. "2101.00001" or "hep-th/9901001") and
+// its version (e.g. "v2"), the version is empty if not given. An "arXiv:" label or
+// arxiv.org URL is removed. The year and month of the id are checked as is the archive
+// of an old style id.
+func ParseArXiv(formValue string) (string, string, error) {
+	val := strings.TrimSpace(formValue)
+	if !ReArXiv.MatchString(val) {
+		return "", "", fmt.Errorf("%q is not an arXiv id", formValue)
+	}
+	isURL := strings.HasPrefix(strings.ToLower(val), "http")
+	val = trimIdentifierPrefix(val, "https://arxiv.org/abs/", "https://arxiv.org/pdf/", "http://arxiv.org/abs/", "http://arxiv.org/pdf/",
+		"https://www.arxiv.org/abs/", "https://www.arxiv.org/pdf/", "http://www.arxiv.org/abs/", "http://www.arxiv.org/pdf/", "arxiv:")
+	if strings.HasSuffix(val, ".pdf") {
+		if !isURL {
+			return "", "", fmt.Errorf("%q is not an arXiv id", formValue)
+		}
+		val = strings.TrimSuffix(val, ".pdf")
+	}
+	id, version := val, ""
+	if i := strings.LastIndex(val, "v"); i > 0 && i > strings.LastIndexAny(val, "./") {
+		id, version = val[0:i], val[i:]
+	}
+	// Old style ids are the archive, subject class, year, month and number, e.g. math.GT/0309136
+	archive, number, oldStyle := strings.Cut(id, "/")
+	if oldStyle {
+		archive, _, _ = strings.Cut(archive, ".")
+		if !inList(arXivArchives, archive) {
+			return "", "", fmt.Errorf("%q is not an arXiv archive", archive)
+		}
+		yy, mm := number[0:2], number[2:4]
+		// NOTE: old style ids were used from August 1991 until March 2007
+		if (yy < "91" && yy > "07") || (yy == "91" && mm < "08") || (yy == "07" && mm > "03") || mm < "01" || mm > "12" {
+			return "", "", fmt.Errorf("%q has an invalid year and month", formValue)
+		}
+		return id, version, nil
+	}
+	yymm, number, _ := strings.Cut(id, ".")
+	mm := yymm[2:4]
+	// NOTE: new style ids started in April 2007, the number has five digits from January 2015
+	if yymm < "0704" || mm < "01" || mm > "12" {
+		return "", "", fmt.Errorf("%q has an invalid year and month", formValue)
+	}
+	if (yymm < "1501" && len(number) != 4) || (yymm >= "1501" && len(number) != 5) {
+		return "", "", fmt.Errorf("%q has a number with the wrong number of digits", formValue)
+	}
+	return id, version, nil
+}
+
+// GeneratePMID sets up an HTML input type text using a pattern for PMID
+func GeneratePMID() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     PMIDPattern,
+			"placeholder": "e.g. 23193287",
+		},
+	}
+}
+
+// ValidatePMID checks a PubMed id, a positive integer of up to nine digits. The
+// id can have a "PMID:" label or be a PubMed URL.
+func ValidatePMID(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, err := parsePMID(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+// GeneratePMCID sets up an HTML input type text using a pattern for PMCID
+func GeneratePMCID() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     PMCIDPattern,
+			"placeholder": "e.g. PMC3531190",
+		},
+	}
+}
+
+// ValidatePMCID checks a PubMed Central id, "PMC" followed by up to nine digits
+// and an optional version. The id can be a PMC article URL.
+func ValidatePMCID(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, _, err := ParsePMCID(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+// GenerateArXiv sets up an HTML input type text using a pattern for arXiv ids
+func GenerateArXiv() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     ArXivPattern,
+			"placeholder": "e.g. 2101.00001v2 or hep-th/9901001",
+		},
+	}
+}
+
+// ValidateArXiv checks a new (2101.00001v2) or old (hep-th/9901001) style arXiv id. The
+// id can have an "arXiv:" label or be an arxiv.org URL.
+func ValidateArXiv(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, _, err := ParseArXiv(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+func init() {
+	ReDOIPrefix = regexp.MustCompile(`^10\.[0-9]{4,}(\.[0-9]+)*$`)
+	RePMID = regexp.MustCompile("^(?:" + PMIDPattern + ")$")
+	RePMCID = regexp.MustCompile("^(?:" + PMCIDPattern + ")$")
+	ReArXiv = regexp.MustCompile("^(?:" + ArXivPattern + ")$")
+}
@@ -0,0 +1,484 @@
+// identifiers_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestDOI tests validating, normalizing and rendering DOIs.
+func TestDOI(t *testing.T) {
+	elem := new(Element)
+	elem.Id = "doi"
+	elem.Type = "doi"
+	re, err := compilePattern(DOIPattern)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, val := range []string{
+		`10.1000/182`,
+		`10.22002/D1.20046`,
+		`doi:10.1016/S0140-6736(20)30183-5`,
+		`DOI:10.1038/nature12373`,
+		`https://doi.org/10.1103/PhysRevLett.116.061102`,
+		`http://dx.doi.org/10.1000.10/abc%23123`,
+	} {
+		if !ValidateDOI(elem, val) {
+			t.Errorf("expected ValidateDOI(elem, %q) to return true, return false", val)
+		}
+		if !re.MatchString(val) {
+			t.Errorf("expected pattern to match %q", val)
+		}
+	}
+	for _, val := range []string{`10.100/182`, `11.1000/182`, `10.1000`, `10.1000/`, `10.1000/a b`, `doi.org/10.1000/182`, `https://example.org/10.1000/182`} {
+		if ValidateDOI(elem, val) {
+			t.Errorf("expected ValidateDOI(elem, %q) to return false, return true", val)
+		}
+		if re.MatchString(val) {
+			t.Errorf("expected pattern not to match %q", val)
+		}
+	}
+
+	for val, expected := range map[string]string{
+		`https://doi.org/10.1103/PhysRevLett.116.061102`: `10.1103/physrevlett.116.061102`,
+		`doi:10.1000/ABC%23123`:                          `10.1000/abc%23123`,
+		`https://doi.org/10.1000/ABC%23123`:              `10.1000/abc#123`,
+		` 10.22002/D1.20046 `:                            `10.22002/d1.20046`,
+		`not a doi`:                                      `not a doi`,
+	} {
+		if got := NormalizeDOI(elem, val); got != expected {
+			t.Errorf("expected NormalizeDOI(elem, %q) to return %q, got %q", val, expected, got)
+		}
+	}
+	val, expected := `doi:10.1000/abc#123`, `https://doi.org/10.1000/abc%23123`
+	if got := NormalizeDOIURL(elem, val); got != expected {
+		t.Errorf("expected NormalizeDOIURL(elem, %q) to return %q, got %q", val, expected, got)
+	}
+
+	src := []byte(`id: test_doi
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: doi
+    type: doi
+    label: DOI
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !model.Validate(map[string]string{"id": "one", "doi": "https://doi.org/10.22002/D1.20046"}) {
+		t.Errorf("expected DOI URL to validate")
+	}
+	if model.Validate(map[string]string{"id": "one", "doi": "10.22002"}) {
+		t.Errorf("expected DOI without a suffix to fail validation")
+	}
+	for renderer, expected := range map[string]string{
+		"html":       `pattern="((https?://(dx\.)?doi\.org/)|([dD][oO][iI]:))?10\.[0-9]{4,}(\.[0-9]+)*/\S+"`,
+		"sqlite":     `doi text`,
+		"typescript": `doi: string = "";`,
+		"python":     `doi: str`,
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		var err error
+		switch renderer {
+		case "html":
+			err = ModelToHTML(buf, model)
+		case "sqlite":
+			err = ModelToSQLiteScheme(buf, model)
+		case "typescript":
+			err = ModelToTypeScriptClass(buf, model)
+		case "python":
+			err = ModelToPythonClass(buf, model)
+		}
+		if err != nil {
+			t.Error(err)
+		} else if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %s in %s\n%s", expected, renderer, buf.String())
+		}
+	}
+}
+
+// TestARK tests validating, normalizing and minting ARKs.
+func TestARK(t *testing.T) {
+	elem := new(Element)
+	elem.Id = "ark"
+	elem.Type = "ark"
+	re, err := compilePattern(ARKPattern)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, val := range []string{
+		`ark:/13030/tf5p30086k`,
+		`ark:13030/tf5p30086k`,
+		`ARK:/13030/tf5p30086k/page.2`,
+		`https://n2t.net/ark:/13030/tf5p30086k`,
+		`https://digital.library.ucla.edu/ark:/21198/z1q00bxr`,
+		`ark:12345/x6-np1-wh8k%2a`,
+		`ark:b5072/fk2`,
+	} {
+		if !ValidateARK(elem, val) {
+			t.Errorf("expected ValidateARK(elem, %q) to return true, return false", val)
+		}
+		if !re.MatchString(val) {
+			t.Errorf("expected pattern to match %q", val)
+		}
+	}
+	for _, val := range []string{`ark:1303/tf5p30086k`, `ark:13030/`, `ark:13030`, `13030/tf5p30086k`, `ark:13030/tf5p 30086k`, `ark:13030/tf5p#30086k`, `https://n2t.net/13030/tf5p30086k`} {
+		if ValidateARK(elem, val) {
+			t.Errorf("expected ValidateARK(elem, %q) to return false, return true", val)
+		}
+		if re.MatchString(val) {
+			t.Errorf("expected pattern not to match %q", val)
+		}
+	}
+
+	for val, expected := range map[string]string{
+		`https://n2t.net/ark:/13030/tf5p30086k`: `ark:13030/tf5p30086k`,
+		`ARK:/13030/tf5p3-0086k/`:               `ark:13030/tf5p30086k`,
+		`ark:13030/tf5p30086k/page-2.`:          `ark:13030/tf5p30086k/page2`,
+		`ark:12345/x6np1wh8k%2a`:                `ark:12345/x6np1wh8k%2A`,
+		`not an ark`:                            `not an ark`,
+	} {
+		if got := NormalizeARK(elem, val); got != expected {
+			t.Errorf("expected NormalizeARK(elem, %q) to return %q, got %q", val, expected, got)
+		}
+	}
+	val, expected := `ark:/13030/tf5p30086k`, `https://n2t.net/ark:13030/tf5p30086k`
+	if got := NormalizeARKURL(elem, val); got != expected {
+		t.Errorf("expected NormalizeARKURL(elem, %q) to return %q, got %q", val, expected, got)
+	}
+
+	// The NOID documentation's example, 13030/xf93gt2 has the check character "q"
+	if c := noidCheckChar("13030/xf93gt2"); c != 'q' {
+		t.Errorf("expected check character q, got %c", c)
+	}
+	if _, err := MintARK("1234", "x6", 0); err == nil {
+		t.Errorf("expected an error minting with NAAN 1234")
+	}
+	if _, err := MintARK("12345", "X6", 0); err == nil {
+		t.Errorf("expected an error minting with shoulder X6")
+	}
+
+	src := []byte(`id: test_ark
+elements:
+  - id: id
+    type: ark
+    is_primary_id: true
+    generator: ark
+    attributes:
+      naan: "12345"
+      shoulder: x6
+      blade_length: "6"
+  - id: title
+    type: text
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	buf := bytes.NewBuffer([]byte{})
+	if !model.Check(buf) {
+		t.Errorf("expected model to check, %s", buf.String())
+	}
+	record := map[string]interface{}{"title": "A minted ARK"}
+	if err := model.GenerateValues(record); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	ark, _ := record["id"].(string)
+	if !strings.HasPrefix(ark, "ark:12345/x6") || len(ark) != len("ark:12345/x6")+7 {
+		t.Errorf("expected an ARK with NAAN 12345 and shoulder x6, got %q", ark)
+	}
+	if !model.ValidateMapInterface(record) {
+		t.Errorf("expected minted ARK %q to validate", ark)
+	}
+	// Changing a character of the blade breaks the check character.
+	c := ark[len(ark)-2 : len(ark)-1]
+	for _, r := range "0123456789bcdfghjkmnpqrstvwxz" {
+		if string(r) != c {
+			bad := ark[0:len(ark)-2] + string(r) + ark[len(ark)-1:]
+			if model.ValidateMapInterface(map[string]interface{}{"id": bad}) {
+				t.Errorf("expected %q to fail the check character test", bad)
+			}
+			break
+		}
+	}
+	// ARKs from other NAANs or shoulders aren't minted by the element so have no check character.
+	if !model.ValidateMapInterface(map[string]interface{}{"id": "ark:13030/tf5p30086k"}) {
+		t.Errorf("expected an ARK from another NAAN to validate")
+	}
+	// An existing value is kept.
+	record = map[string]interface{}{"id": "ark:12345/x6np1wh8k"}
+	if err := model.GenerateValues(record); err != nil || record["id"] != "ark:12345/x6np1wh8k" {
+		t.Errorf("expected the existing ARK to be kept, got %v, %v", record["id"], err)
+	}
+
+	delete(model.Elements[0].Attributes, "naan")
+	buf.Reset()
+	if model.Check(buf) {
+		t.Errorf("expected model without a NAAN to fail check")
+	}
+	if err := model.GenerateValues(map[string]interface{}{}); err == nil {
+		t.Errorf("expected an error minting without a NAAN")
+	}
+}
+
+// TestISBN tests validating and normalizing ISBN-10 and ISBN-13.
+func TestISBN(t *testing.T) {
+	elem := new(Element)
+	elem.Id = "isbn"
+	elem.Type = "isbn"
+	re, err := compilePattern(ISBNPattern)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, val := range []string{`0-306-40615-2`, `0306406152`, `0 306 40615 2`, `0-8044-2957-X`, `080442957x`, `978-0-306-40615-7`, `9780198526636`, `979-10-90636-07-1`} {
+		if !ValidateISBN(elem, val) {
+			t.Errorf("expected ValidateISBN(elem, %q) to return true, return false", val)
+		}
+		if !re.MatchString(val) {
+			t.Errorf("expected pattern to match %q", val)
+		}
+	}
+	// Bad check digits
+	for _, val := range []string{`0-306-40615-3`, `0-8044-2957-0`, `978-0-306-40615-2`, `9790198526636`} {
+		if ValidateISBN(elem, val) {
+			t.Errorf("expected ValidateISBN(elem, %q) to return false, return true", val)
+		}
+	}
+	// Badly formatted
+	for _, val := range []string{`0-306--40615-2`, `-0306406152`, `030640615`, `978030640615X`, `977-0-306-40615-7`} {
+		if ValidateISBN(elem, val) {
+			t.Errorf("expected ValidateISBN(elem, %q) to return false, return true", val)
+		}
+		if re.MatchString(val) {
+			t.Errorf("expected pattern not to match %q", val)
+		}
+	}
+
+	for val, expected := range map[string]string{
+		// NOTE: without ranges the submitted hyphens or spaces are not trusted
+		`0-306-40615-2`:     `9780306406157`,
+		`0 8044 2957 X`:     `9780804429573`,
+		`979 10 90636 07 1`: `9791090636071`,
+		`0-3064-0615-2`:     `9780306406157`,
+		`0306406152`:        `9780306406157`,
+		` 9780306406157 `:   `9780306406157`,
+		`not an isbn`:       `not an isbn`,
+	} {
+		if got := NormalizeISBN(elem, val); got != expected {
+			t.Errorf("expected NormalizeISBN(elem, %q) to return %q, got %q", val, expected, got)
+		}
+	}
+
+	// Without ranges an ISBN-13 is normalized to its digits
+	model := new(Model)
+	model.Id = "test_isbn"
+	model.Elements = []*Element{{Id: "isbn", Type: "isbn"}}
+	SetDefaultTypes(model)
+	if _, changes := model.Normalize(map[string]interface{}{"isbn": "9780306406157"}); len(changes) != 0 {
+		t.Errorf("expected no changes to an ISBN-13 without ISBN ranges, got %+v", changes)
+	}
+	if _, changes := model.Normalize(map[string]interface{}{"isbn": "0 306 40615 2"}); len(changes) != 1 || changes[0].To != "9780306406157" {
+		t.Errorf("expected 0 306 40615 2 to be converted to an ISBN-13, got %+v", changes)
+	}
+
+	// An excerpt in the form of the ISBN Agency's RangeMessage.xml
+	src := `<?xml version="1.0" encoding="utf-8"?>
+<ISBNRangeMessage>
+  <RegistrationGroups>
+    <Group>
+      <Prefix>978-0</Prefix>
+      <Agency>English language</Agency>
+      <Rules>
+        <Rule><Range>0000000-1999999</Range><Length>2</Length></Rule>
+        <Rule><Range>2000000-6999999</Range><Length>3</Length></Rule>
+        <Rule><Range>7000000-8499999</Range><Length>4</Length></Rule>
+        <Rule><Range>8500000-9999999</Range><Length>0</Length></Rule>
+      </Rules>
+    </Group>
+  </RegistrationGroups>
+</ISBNRangeMessage>`
+	if err := LoadISBNRanges(strings.NewReader(src)); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer func() {
+		isbnRanges = nil
+	}()
+	for val, expected := range map[string]string{
+		`0306406152`:        `978-0-306-40615-7`,
+		`0-3064-0615-2`:     `978-0-306-40615-7`,
+		`9780198526636`:     `978-0-19-852663-6`,
+		`080442957X`:        `978-0-8044-2957-3`,
+		`9780900000003`:     `9780900000003`,
+		`979-10-90636-07-1`: `9791090636071`,
+	} {
+		if got := NormalizeISBN(elem, val); got != expected {
+			t.Errorf("expected NormalizeISBN(elem, %q) with ranges to return %q, got %q", val, expected, got)
+		}
+	}
+	if err := LoadISBNRanges(strings.NewReader(`<ISBNRangeMessage><RegistrationGroups><Group><Prefix>978-0</Prefix><Rules><Rule><Range>0000000</Range><Length>2</Length></Rule></Rules></Group></RegistrationGroups></ISBNRangeMessage>`)); err == nil {
+		t.Errorf("expected an error loading an invalid range")
+	}
+}
+
+// TestISSN tests validating and normalizing ISSNs.
+func TestISSN(t *testing.T) {
+	elem := new(Element)
+	elem.Id = "issn"
+	elem.Type = "issn"
+	re, err := compilePattern(ISSNPattern)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, val := range []string{`0317-8471`, `0028-0836`, `2434-561X`, `2434 561x`, `1050124X`} {
+		if !ValidateISSN(elem, val) {
+			t.Errorf("expected ValidateISSN(elem, %q) to return true, return false", val)
+		}
+		if !re.MatchString(val) {
+			t.Errorf("expected pattern to match %q", val)
+		}
+	}
+	for _, val := range []string{`0317-8472`, `2434-5610`, `0317-847`, `03178-471`, `0317--8471`} {
+		if ValidateISSN(elem, val) {
+			t.Errorf("expected ValidateISSN(elem, %q) to return false, return true", val)
+		}
+	}
+	for val, expected := range map[string]string{
+		`2434 561x`: `2434-561X`,
+		`00280836`:  `0028-0836`,
+		`0317-8471`: `0317-8471`,
+	} {
+		if got := NormalizeISSN(elem, val); got != expected {
+			t.Errorf("expected NormalizeISSN(elem, %q) to return %q, got %q", val, expected, got)
+		}
+	}
+}
+
+// TestPubMedAndArXiv tests validating and normalizing PMID, PMCID and arXiv ids.
+func TestPubMedAndArXiv(t *testing.T) {
+	type testCase struct {
+		pattern   string
+		validate  ValidateFunc
+		normalize NormalizeFunc
+		valid     map[string]string
+		invalid   []string
+	}
+	for typeName, tc := range map[string]testCase{
+		"pmid": {
+			pattern:   PMIDPattern,
+			validate:  ValidatePMID,
+			normalize: NormalizePMID,
+			valid: map[string]string{
+				`23193287`:       `23193287`,
+				`PMID: 23193287`: `23193287`,
+				`pmid:23193287`:  `23193287`,
+				`https://pubmed.ncbi.nlm.nih.gov/23193287/`: `23193287`,
+			},
+			invalid: []string{`023193287`, `PMID 23193287`, `1234567890`, `https://pubmed.ncbi.nlm.nih.gov/`, `PMC3531190`},
+		},
+		"pmcid": {
+			pattern:   PMCIDPattern,
+			validate:  ValidatePMCID,
+			normalize: NormalizePMCID,
+			valid: map[string]string{
+				`PMC3531190`:   `PMC3531190`,
+				`pmc3531190`:   `PMC3531190`,
+				`PMC3531190.2`: `PMC3531190.2`,
+				`https://www.ncbi.nlm.nih.gov/pmc/articles/PMC3531190/`: `PMC3531190`,
+				`https://pmc.ncbi.nlm.nih.gov/articles/PMC3531190.1/`:   `PMC3531190.1`,
+			},
+			invalid: []string{`3531190`, `PMC`, `PMC3531190.`, `PMCID: PMC3531190`, `https://example.org/articles/PMC3531190/`},
+		},
+		"arxiv": {
+			pattern:   ArXivPattern,
+			validate:  ValidateArXiv,
+			normalize: NormalizeArXiv,
+			valid: map[string]string{
+				`2101.00001v2`:                              `2101.00001v2`,
+				`arXiv:2101.00001v2`:                        `2101.00001v2`,
+				`https://arxiv.org/abs/2101.00001v2`:        `2101.00001v2`,
+				`https://arxiv.org/pdf/2101.00001.pdf`:      `2101.00001`,
+				`0704.0001`:                                 `0704.0001`,
+				`1412.7878v1`:                               `1412.7878v1`,
+				`hep-th/9901001`:                            `hep-th/9901001`,
+				`arXiv:hep-th/9901001v3`:                    `hep-th/9901001v3`,
+				`math.GT/0309136`:                           `math.GT/0309136`,
+				`solv-int/9901001`:                          `solv-int/9901001`,
+				`http://www.arxiv.org/abs/astro-ph/0703001`: `astro-ph/0703001`,
+			},
+			invalid: []string{`2101.0001`, `1412.00001`, `0703.0001`, `2113.00001`, `2101.00001v0`, `arXiv:2101.00001.pdf`,
+				`foo-bar/9901001`, `hep-th/0801001`, `hep-th/9101001`, `hep-th/990100`, `hep-th 9901001`},
+		},
+	} {
+		re, err := compilePattern(tc.pattern)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		elem := &Element{Id: typeName, Type: typeName}
+		for val, expected := range tc.valid {
+			if !tc.validate(elem, val) {
+				t.Errorf("expected %s %q to validate", typeName, val)
+			}
+			if !re.MatchString(val) {
+				t.Errorf("expected %s pattern to match %q", typeName, val)
+			}
+			if got := tc.normalize(elem, val); got != expected {
+				t.Errorf("expected %s %q to normalize to %q, got %q", typeName, val, expected, got)
+			}
+		}
+		for _, val := range tc.invalid {
+			if tc.validate(elem, val) {
+				t.Errorf("expected %s %q to fail validation", typeName, val)
+			}
+		}
+	}
+
+	id, version, err := ParseArXiv(`https://arxiv.org/abs/hep-th/9901001v2`)
+	if err != nil || id != "hep-th/9901001" || version != "v2" {
+		t.Errorf("expected hep-th/9901001 and v2, got %q, %q, %v", id, version, err)
+	}
+	id, version, err = ParsePMCID(`PMC3531190.2`)
+	if err != nil || id != "PMC3531190" || version != "2" {
+		t.Errorf("expected PMC3531190 and 2, got %q, %q, %v", id, version, err)
+	}
+}
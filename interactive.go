@@ -355,34 +355,38 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 	for quit := false; !quit; {
 		attributeList := getAttributeIds(elem.Attributes)
 		switch elem.Type {
-		case "select":
+		case "select", "radio":
 			optionsList := getValueLabelList(elem.Options)
 			menu, opt = prompt.SelectMenu(
 				fmt.Sprintf("Modify element %s.%s", model.Id, elementId),
-				"Choices [t]ype, [l]abel, [a]ttributes, [o]ptions or press enter when done",
+				"Choices [t]ype, [l]abel, [a]ttributes, [o]ptions, [r]equired if, [s]how if or press enter when done",
 				[]string{
 					fmt.Sprintf("id %s", elementId),
 					fmt.Sprintf("type %s", elem.Type),
 					fmt.Sprintf("label %s", elem.Label),
 					fmt.Sprintf("attributes:\n\t\t%s", strings.Join(attributeList, ",\n\t\t")),
 					fmt.Sprintf("options:\n\t\t%s", strings.Join(optionsList, ",\n\t\t")),
+					fmt.Sprintf("required if %s", elem.RequiredIf),
+					fmt.Sprintf("show if %s", elem.ShowIf),
 				},
 				"", "", true)
 		case "textarea":
 			menu, opt = prompt.SelectMenu(
 				fmt.Sprintf("Modify element %s.%s", model.Id, elementId),
-				"Choices [t]ype, [l]abel, [a]ttributes or press enter when done",
+				"Choices [t]ype, [l]abel, [a]ttributes, [r]equired if, [s]how if or press enter when done",
 				[]string{
 					fmt.Sprintf("id %s", elementId),
 					fmt.Sprintf("type %s", elem.Type),
 					fmt.Sprintf("label %s", elem.Label),
 					fmt.Sprintf("attributes:\n\t\t%s", strings.Join(attributeList, ",\n\t\t")),
+					fmt.Sprintf("required if %s", elem.RequiredIf),
+					fmt.Sprintf("show if %s", elem.ShowIf),
 				},
 				"", "", true)
 		default:
 			menu, opt = prompt.SelectMenu(
 				fmt.Sprintf("Manage %s.%s element", model.Id, elementId),
-				"Choices [t]ype, [l]abel, [o]bject identifier, [p]attern, [a]ttributes, [g]enerator or press enter when done",
+				"Choices [t]ype, [l]abel, [o]bject identifier, [p]attern, [a]ttributes, [g]enerator, [r]equired if, [s]how if or press enter when done",
 				[]string{
 					fmt.Sprintf("id %s", elementId),
 					fmt.Sprintf("type %s", elem.Type),
@@ -391,6 +395,8 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 					fmt.Sprintf("attributes:\n\t\t%s", strings.Join(attributeList, ",\n\t\t")),
 					fmt.Sprintf("object identifier? %t", elem.IsObjectId),
 					fmt.Sprintf("generator %s", elem.Generator),
+					fmt.Sprintf("required if %s", elem.RequiredIf),
+					fmt.Sprintf("show if %s", elem.ShowIf),
 				},
 				"", "", true)
 		}
@@ -408,10 +414,9 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 				if ok := model.IsSupportedElementType(eType); !ok {
 					fmt.Fprintf(eout, "%q is not a supported element type", opt)
 				} else {
-					// FIXME: If elem.Type is select I need to provide an choices TUI for values and labels
 					elem.Type, elem.Pattern = normalizeInputType(eType)
 					elem.Changed(true)
-					if elem.Type == "select" {
+					if elem.Type == "select" || elem.Type == "radio" {
 						if err := modifySelectElementTUI(elem, in, out, eout, model.Id); err != nil {
 							fmt.Fprintf(eout, "ERROR (%q): %s\n", elementId, err)
 						}
@@ -450,7 +455,7 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 				fmt.Fprintf(eout, "%s\n", err)
 			}
 		case "o":
-			if elem.Type == "select" {
+			if elem.Type == "select" || elem.Type == "radio" {
 				if err := modifySelectElementTUI(elem, in, out, eout, model.Id); err != nil {
 					fmt.Fprintf(eout, "ERROR (%q): %s\n", elem.Id, err)
 				}
@@ -460,7 +465,7 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 			}
 		case "g":
 			if opt == "" {
-				fmt.Fprintf(out, "Enter generator (e.g. autoincrement, uuid, current_timestamp, created_timestamp, current_date, created_date) ")
+				fmt.Fprintf(out, "Enter generator (e.g. autoincrement, uuid, ark, current_timestamp, created_timestamp, current_date, created_date) ")
 				opt = prompt.GetAnswer("", true)
 			}
 			fmt.Fprintf(out, "DEBUG opt -> %q, elem.Generator -> %q\n", opt, elem.Generator)
@@ -474,6 +479,24 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 					elem.Changed(true)
 				}
 			}
+		case "r":
+			if opt == "" {
+				fmt.Fprintf(out, "Enter the condition making the element required (e.g. resource_type == \"thesis\"), * to remove: ")
+				opt = prompt.GetAnswer("", false)
+			}
+			if cond, ok := getConditionAnswer(model, opt, eout); ok && cond != elem.RequiredIf {
+				elem.RequiredIf = cond
+				elem.Changed(true)
+			}
+		case "s":
+			if opt == "" {
+				fmt.Fprintf(out, "Enter the condition showing the element (e.g. resource_type == \"thesis\"), * to remove: ")
+				opt = prompt.GetAnswer("", false)
+			}
+			if cond, ok := getConditionAnswer(model, opt, eout); ok && cond != elem.ShowIf {
+				elem.ShowIf = cond
+				elem.Changed(true)
+			}
 		case "q":
 			quit = true
 		case "":
@@ -485,6 +508,23 @@ func modifyElementTUI(model *Model, in io.Reader, out io.Writer, eout io.Writer,
 	return nil
 }
 
+// getConditionAnswer checks a condition entered for required_if or show_if. An
+// astrix removes the condition. It returns false if the condition was empty or not valid.
+func getConditionAnswer(model *Model, opt string, eout io.Writer) (string, bool) {
+	opt = strings.TrimSpace(opt)
+	switch opt {
+	case "":
+		return "", false
+	case "*":
+		return "", true
+	}
+	if err := checkCondition(model, opt); err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		return "", false
+	}
+	return opt, true
+}
+
 func removeElementFromModel(model *Model, elementId string) error {
 	for i, elem := range model.Elements {
 		if elem.Id == elementId {
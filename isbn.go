@@ -0,0 +1,271 @@
+// isbn.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ISBNPattern matches an ISBN-13 or ISBN-10. Digits can be separated by single hyphens or spaces.
+	ISBNPattern = `97[89]([\- ]?[0-9]){10}|[0-9]([\- ]?[0-9]){8}[\- ]?[0-9Xx]`
+
+	// ISSNPattern matches an ISSN with or without the hyphen (or a space) between its halves.
+	ISSNPattern = `[0-9]{4}[\- ]?[0-9]{3}[0-9Xx]`
+)
+
+var (
+	// ReISBN matches an ISBN (see ISBNPattern)
+	ReISBN *regexp.Regexp
+
+	// ReISSN matches an ISSN (see ISSNPattern)
+	ReISSN *regexp.Regexp
+
+	// isbnRanges holds the registrant ranges of each registration group by
+	// prefix, e.g. "9780", loaded with LoadISBNRanges.
+	isbnRanges   map[string][]isbnRule
+	isbnRangesMu sync.RWMutex
+)
+
+// isbnRule maps a range of the seven digits following a registration group to the
+// length of the registrant element.
+type isbnRule struct {
+	low, high int
+	length    int
+}
+
+// isbnRangeMessage holds the parts of the International ISBN Agency's RangeMessage.xml
+// used to hyphenate ISBNs.
+type isbnRangeMessage struct {
+	Groups []struct {
+		Prefix string `xml:"Prefix"`
+		Rules  []struct {
+			Range  string `xml:"Range"`
+			Length int    `xml:"Length"`
+		} `xml:"Rules>Rule"`
+	} `xml:"RegistrationGroups>Group"`
+}
+
+// LoadISBNRanges loads the registrant ranges used to hyphenate ISBNs from the International
+// ISBN Agency's RangeMessage.xml, <https://www.isbn-international.org/range_file_generation>.
+// The ranges change as new ones are assigned so they aren't built in. Without them an ISBN
+// is normalized to thirteen digits without hyphens.
+func LoadISBNRanges(src io.Reader) error {
+	msg := new(isbnRangeMessage)
+	if err := xml.NewDecoder(src).Decode(msg); err != nil {
+		return err
+	}
+	ranges := map[string][]isbnRule{}
+	for _, group := range msg.Groups {
+		prefix := strings.ReplaceAll(group.Prefix, "-", "")
+		for _, rule := range group.Rules {
+			low, high, ok := strings.Cut(rule.Range, "-")
+			if !ok {
+				return fmt.Errorf("group %s has an invalid range %q", group.Prefix, rule.Range)
+			}
+			l, err := strconv.Atoi(low)
+			if err != nil {
+				return fmt.Errorf("group %s has an invalid range %q", group.Prefix, rule.Range)
+			}
+			h, err := strconv.Atoi(high)
+			if err != nil {
+				return fmt.Errorf("group %s has an invalid range %q", group.Prefix, rule.Range)
+			}
+			ranges[prefix] = append(ranges[prefix], isbnRule{low: l, high: h, length: rule.Length})
+		}
+	}
+	isbnRangesMu.Lock()
+	defer isbnRangesMu.Unlock()
+	isbnRanges = ranges
+	return nil
+}
+
+// hyphenateISBN13 splits an ISBN-13 into its prefix, registration group, registrant,
+// publication and check digit using the loaded ranges. It returns false if the ISBN
+// isn't covered by the ranges.
+func hyphenateISBN13(digits string) (string, bool) {
+	isbnRangesMu.RLock()
+	defer isbnRangesMu.RUnlock()
+	for prefix, rules := range isbnRanges {
+		if len(prefix) < 4 || !strings.HasPrefix(digits, prefix) {
+			continue
+		}
+		rest := digits[len(prefix):12]
+		val, err := strconv.Atoi((rest + "0000000")[0:7])
+		if err != nil {
+			return "", false
+		}
+		for _, rule := range rules {
+			if val < rule.low || val > rule.high {
+				continue
+			}
+			// NOTE: a length of zero marks a range that hasn't been assigned
+			if rule.length == 0 || rule.length >= len(rest) {
+				return "", false
+			}
+			return strings.Join([]string{digits[0:3], prefix[3:], rest[0:rule.length], rest[rule.length:], digits[12:]}, "-"), true
+		}
+	}
+	return "", false
+}
+
+// isbnCheckDigit10 calculates the check digit of an ISBN-10 from its first nine digits.
+// The weights run from ten down to two, the check digit makes the sum a multiple of 11.
+func isbnCheckDigit10(digits string) string {
+	total := 0
+	for i := 0; i < 9; i++ {
+		total += (10 - i) * int(digits[i]-'0')
+	}
+	ck := (11 - total%11) % 11
+	if ck == 10 {
+		return "X"
+	}
+	return strconv.Itoa(ck)
+}
+
+// isbnCheckDigit13 calculates the check digit of an ISBN-13 (an EAN-13) from its first
+// twelve digits. The weights alternate between one and three, the check digit makes the
+// sum a multiple of ten.
+func isbnCheckDigit13(digits string) string {
+	total := 0
+	for i := 0; i < 12; i++ {
+		if i%2 == 0 {
+			total += int(digits[i] - '0')
+		} else {
+			total += 3 * int(digits[i]-'0')
+		}
+	}
+	return strconv.Itoa((10 - total%10) % 10)
+}
+
+// issnCheckDigit calculates the check digit of an ISSN from its first seven digits.
+// The weights run from eight down to two, the check digit makes the sum a multiple of 11.
+func issnCheckDigit(digits string) string {
+	total := 0
+	for i := 0; i < 7; i++ {
+		total += (8 - i) * int(digits[i]-'0')
+	}
+	ck := (11 - total%11) % 11
+	if ck == 10 {
+		return "X"
+	}
+	return strconv.Itoa(ck)
+}
+
+// parseISBN checks an ISBN-10 or ISBN-13 and returns its digits (with an uppercase "X").
+func parseISBN(formValue string) (string, error) {
+	val := strings.TrimSpace(formValue)
+	if !ReISBN.MatchString(val) {
+		return "", fmt.Errorf("%q is not formatted as an ISBN-10 or ISBN-13", formValue)
+	}
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(val))
+	ck := ""
+	if len(digits) == 10 {
+		ck = isbnCheckDigit10(digits)
+	} else {
+		ck = isbnCheckDigit13(digits)
+	}
+	if !strings.HasSuffix(digits, ck) {
+		return "", fmt.Errorf("%q has the wrong check digit, expected %s", formValue, ck)
+	}
+	return digits, nil
+}
+
+// GenerateISBN sets up an HTML input type text using a pattern for ISBN
+func GenerateISBN() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     ISBNPattern,
+			"placeholder": "e.g. 978-0-306-40615-7 or 0-306-40615-2",
+		},
+	}
+}
+
+// ValidateISBN checks an ISBN-10 (mod 11 check digit) or ISBN-13 (mod 10 check digit).
+// Hyphens or spaces can separate the digits.
+func ValidateISBN(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	if _, err := parseISBN(formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
+		return false
+	}
+	return true
+}
+
+// GenerateISSN sets up an HTML input type text using a pattern for ISSN
+func GenerateISSN() *Element {
+	return &Element{
+		Type: "text",
+		Attributes: map[string]string{
+			"pattern":     ISSNPattern,
+			"placeholder": "e.g. 0317-8471",
+		},
+	}
+}
+
+// ValidateISSN checks an ISSN's mod 11 check digit. A hyphen or space can separate its halves.
+func ValidateISSN(elem *Element, formValue string) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	val := strings.TrimSpace(formValue)
+	if !ReISSN.MatchString(val) {
+		if Debug {
+			log.Printf("DEBUG failed to validate pattern elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+		}
+		return false
+	}
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(val))
+	ck := issnCheckDigit(digits)
+	if Debug {
+		log.Printf("DEBUG validating issn elem.Id %q, elem.Type %q, value %q, result: %t\n", elem.Id, elem.Type, formValue, digits[7:] == ck)
+	}
+	return digits[7:] == ck
+}
+
+func init() {
+	ReISBN = regexp.MustCompile("^(?:" + ISBNPattern + ")$")
+	ReISSN = regexp.MustCompile("^(?:" + ISSNPattern + ")$")
+}
@@ -0,0 +1,272 @@
+// locale.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	// 3rd Party packages
+	"golang.org/x/text/collate"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/message"
+)
+
+// Language, country, script and currency codes are checked and canonicalized with
+// golang.org/x/text. The options listed in a web form are named in the language given
+// by the element's (or form's) "lang" attribute, English by default.
+
+var (
+	// ReCountryCode matches an ISO 3166-1 alpha-2 or alpha-3 code
+	ReCountryCode = regexp.MustCompile(`^[A-Za-z]{2,3}$`)
+
+	// ReScriptCode matches an ISO 15924 code
+	ReScriptCode = regexp.MustCompile(`^[A-Za-z]{4}$`)
+
+	// ReCurrencyCode matches an ISO 4217 code
+	ReCurrencyCode = regexp.MustCompile(`^[A-Za-z]{3}$`)
+)
+
+// ParseLanguage parses a BCP 47 language tag returning it in its canonical form,
+// e.g. "en_us" becomes "en-US" and "iw" becomes "he".
+func ParseLanguage(s string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	return tag.String(), nil
+}
+
+// ParseCountry parses an ISO 3166-1 alpha-2 or alpha-3 country code returning the
+// canonical alpha-2 code, e.g. "usa" becomes "US". Deprecated codes are replaced,
+// e.g. "BU" becomes "MM". Codes of regions that aren't countries (e.g. "EU") and
+// user assigned codes (e.g. "XK") are rejected.
+func ParseCountry(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !ReCountryCode.MatchString(s) {
+		return "", fmt.Errorf("%q is not an alpha-2 or alpha-3 country code", s)
+	}
+	region, err := language.ParseRegion(s)
+	if err != nil {
+		return "", err
+	}
+	if !region.IsCountry() || region.IsPrivateUse() {
+		return "", fmt.Errorf("%q is not a country code", s)
+	}
+	return region.Canonicalize().String(), nil
+}
+
+// ParseScript parses an ISO 15924 script code returning it in title case, e.g. "latn" becomes "Latn".
+func ParseScript(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !ReScriptCode.MatchString(s) {
+		return "", fmt.Errorf("%q is not a four letter script code", s)
+	}
+	script, err := language.ParseScript(s)
+	if err != nil {
+		return "", err
+	}
+	return script.String(), nil
+}
+
+// ParseCurrency parses an ISO 4217 currency code returning it in upper case, e.g. "usd" becomes "USD".
+func ParseCurrency(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !ReCurrencyCode.MatchString(s) {
+		return "", fmt.Errorf("%q is not a three letter currency code", s)
+	}
+	unit, err := currency.ParseISO(s)
+	if err != nil {
+		return "", err
+	}
+	return unit.String(), nil
+}
+
+// validateCode checks a value with a code's parser. If the element has options the
+// canonical code must be one of them. An empty value (e.g. the select's empty option)
+// is left to the element's required attribute.
+func validateCode(elem *Element, formValue string, parse func(string) (string, error)) bool {
+	if Debug {
+		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
+	}
+	if formValue == "" {
+		return true
+	}
+	code, err := parse(formValue)
+	if err != nil {
+		if Debug {
+			log.Printf("DEBUG elem.Id %q, %s", elem.Id, err)
+		}
+		return false
+	}
+	if len(elem.Options) > 0 {
+		return elem.HasOption(code)
+	}
+	return true
+}
+
+// GenerateLanguage sets up a language element, rendered as a text input suggesting languages
+func GenerateLanguage() *Element {
+	return &Element{
+		Type: "language",
+		Attributes: map[string]string{
+			"placeholder": "e.g. en, en-GB or zh-Hant",
+			"title":       "A BCP 47 language tag",
+		},
+	}
+}
+
+// ValidateLanguage checks a value is a well-formed BCP 47 language tag with known subtags.
+func ValidateLanguage(elem *Element, formValue string) bool {
+	return validateCode(elem, formValue, ParseLanguage)
+}
+
+// GenerateCountry sets up a country element, rendered as a select listing the countries
+func GenerateCountry() *Element {
+	return &Element{
+		Type:       "country",
+		Attributes: map[string]string{},
+	}
+}
+
+// ValidateCountry checks a value is an ISO 3166-1 alpha-2 or alpha-3 country code.
+func ValidateCountry(elem *Element, formValue string) bool {
+	return validateCode(elem, formValue, ParseCountry)
+}
+
+// GenerateScript sets up a script element, rendered as a select listing the scripts
+func GenerateScript() *Element {
+	return &Element{
+		Type:       "script",
+		Attributes: map[string]string{},
+	}
+}
+
+// ValidateScript checks a value is an ISO 15924 script code.
+func ValidateScript(elem *Element, formValue string) bool {
+	return validateCode(elem, formValue, ParseScript)
+}
+
+// GenerateCurrency sets up a currency element, rendered as a select listing the currencies
+func GenerateCurrency() *Element {
+	return &Element{
+		Type:       "currency",
+		Attributes: map[string]string{},
+	}
+}
+
+// ValidateCurrency checks a value is an ISO 4217 currency code.
+func ValidateCurrency(elem *Element, formValue string) bool {
+	return validateCode(elem, formValue, ParseCurrency)
+}
+
+// displayLanguage returns the language named by a "lang" attribute, English if it
+// is missing or names a language without display names.
+func displayLanguage(lang string) language.Tag {
+	if lang == "" {
+		return language.English
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return language.English
+	}
+	matcher := language.NewMatcher(display.Supported.Tags())
+	if _, _, confidence := matcher.Match(tag); confidence == language.No {
+		return language.English
+	}
+	return tag
+}
+
+// CodeOptions returns the options listing the codes of a language, country, script or
+// currency element with their names in the display language (e.g. "fr" lists "DE" as
+// "Allemagne"), sorted by name. The languages listed are those with display names, other
+// language tags are still valid. Currencies are the ones currently in use, labeled with
+// their code and symbol as names aren't available.
+func CodeOptions(typeName string, lang string) []map[string]string {
+	tag := displayLanguage(lang)
+	type option struct {
+		val   string
+		label string
+	}
+	options := []option{}
+	switch typeName {
+	case "language":
+		namer := display.Tags(tag)
+		for _, t := range display.Supported.Tags() {
+			if name := namer.Name(t); name != "" {
+				options = append(options, option{t.String(), name})
+			}
+		}
+	case "country":
+		namer := display.Regions(tag)
+		for _, region := range language.Supported.Regions() {
+			if !region.IsCountry() || region.IsPrivateUse() || region.Canonicalize() != region {
+				continue
+			}
+			if name := namer.Name(region); name != "" {
+				options = append(options, option{region.String(), name})
+			}
+		}
+	case "script":
+		namer := display.Scripts(tag)
+		for _, script := range language.Supported.Scripts() {
+			if name := namer.Name(script); name != "" {
+				options = append(options, option{script.String(), name})
+			}
+		}
+	case "currency":
+		printer := message.NewPrinter(tag)
+		seen := map[string]bool{}
+		for iter := currency.Query(); iter.Next(); {
+			unit := iter.Unit()
+			code := unit.String()
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			label := code
+			if symbol := printer.Sprint(currency.Symbol(unit)); symbol != code {
+				label = fmt.Sprintf("%s (%s)", code, symbol)
+			}
+			options = append(options, option{code, label})
+		}
+	}
+	collator := collate.New(tag)
+	sort.SliceStable(options, func(i, j int) bool {
+		return collator.CompareString(options[i].label, options[j].label) < 0
+	})
+	list := []map[string]string{}
+	for _, o := range options {
+		list = append(list, map[string]string{o.val: o.label})
+	}
+	return list
+}
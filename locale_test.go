@@ -0,0 +1,189 @@
+// locale_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestCodes tests validating and normalizing language, country, script and currency codes.
+func TestCodes(t *testing.T) {
+	// type -> value -> canonical form
+	expected := map[string]map[string]string{
+		"language": {"en": "en", "en_us": "en-US", "EN-gb": "en-GB", "zh-hant-tw": "zh-Hant-TW", "iw": "he", "i-klingon": "tlh"},
+		"country":  {"US": "US", "us": "US", "USA": "US", "gbr": "GB", "BU": "MM", "AQ": "AQ"},
+		"script":   {"Latn": "Latn", "latn": "Latn", "HANT": "Hant", "Zyyy": "Zyyy"},
+		"currency": {"USD": "USD", "eur": "EUR", "XAU": "XAU"},
+	}
+	rejected := map[string][]string{
+		"language": {"xx", "english", "en-"},
+		"country":  {"840", "EU", "ZZ", "XK", "QO", "United States", "U"},
+		"script":   {"Lat", "Abcd", "Latin"},
+		"currency": {"ABC", "US$", "dollar"},
+	}
+	model := new(Model)
+	for typeName, values := range expected {
+		elem := &Element{Id: typeName, Type: typeName}
+		validator, normalizer := model.validatorFor(typeName), model.normalizerFor(typeName)
+		if validator == nil || normalizer == nil {
+			t.Errorf("expected %s to have a validator and a normalizer", typeName)
+			continue
+		}
+		for val, e := range values {
+			if !validator(elem, val) {
+				t.Errorf("expected %s %q to validate", typeName, val)
+			}
+			if got := normalizer(elem, val); got != e {
+				t.Errorf("expected %s %q to normalize to %q, got %q", typeName, val, e, got)
+			}
+		}
+		for _, val := range rejected[typeName] {
+			if validator(elem, val) {
+				t.Errorf("expected %s %q to fail validation", typeName, val)
+			}
+		}
+	}
+
+	// A blank optional field validates, the select's first option is empty
+	src := []byte(`id: place
+elements:
+  - id: lang
+    type: language
+  - id: country
+    type: country
+  - id: script
+    type: script
+  - id: price_currency
+    type: currency
+    attributes:
+      required: "true"
+`)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if result := model.ValidateReport(map[string]string{"lang": "", "country": "", "script": "", "price_currency": "USD"}); !result.OK() {
+		t.Errorf("expected blank optional codes to validate, %s", result)
+	}
+	if result := model.ValidateReport(map[string]string{"lang": "", "country": "", "script": "", "price_currency": ""}); result.OK() {
+		t.Errorf("expected a blank required currency to fail validation")
+	}
+
+	// Options limit the codes allowed
+	elem := &Element{Id: "country", Type: "country", Options: []map[string]string{{"US": ""}, {"CA": "Canada"}}}
+	if !ValidateCountry(elem, "usa") || ValidateCountry(elem, "MX") {
+		t.Errorf("expected country to be limited to its options")
+	}
+}
+
+// TestCodeOptions tests listing codes with localized names.
+func TestCodeOptions(t *testing.T) {
+	for typeName, expected := range map[string]map[string]string{
+		"language": {"de": "German", "en-GB": "British English"},
+		"country":  {"DE": "Germany", "US": "United States"},
+		"script":   {"Latn": "Latin", "Cyrl": "Cyrillic"},
+		"currency": {"EUR": "EUR (€)", "USD": "USD ($)"},
+	} {
+		options := map[string]string{}
+		for _, option := range CodeOptions(typeName, "") {
+			val, label, _ := getValAndLabel(option)
+			options[val] = label
+		}
+		for val, label := range expected {
+			if options[val] != label {
+				t.Errorf("expected %s option %q to be %q, got %q", typeName, val, label, options[val])
+			}
+		}
+	}
+	countries := CodeOptions("country", "fr")
+	if _, label, _ := getValAndLabel(countries[0]); label != "Afghanistan" {
+		t.Errorf("expected countries to be sorted by name, got %q first", label)
+	}
+	for _, option := range countries {
+		if val, label, _ := getValAndLabel(option); val == "DE" && label != "Allemagne" {
+			t.Errorf("expected DE to be named Allemagne in French, got %q", label)
+		}
+		if _, ok := option["UK"]; ok {
+			t.Errorf("expected deprecated code UK to be left out")
+		}
+	}
+	// An unsupported display language falls back to English
+	if options := CodeOptions("script", "xx"); len(options) == 0 || options[0] == nil {
+		t.Errorf("expected scripts listed in English")
+	}
+}
+
+// TestCodeElementsToHTML tests rendering language, country, script and currency elements.
+func TestCodeElementsToHTML(t *testing.T) {
+	src := []byte(`id: place
+attributes:
+  lang: fr
+elements:
+  - id: lang
+    type: language
+    label: Language
+  - id: country
+    type: country
+    label: Country
+    attributes:
+      required: "true"
+  - id: script
+    type: script
+    options:
+      - Latn: ""
+      - Cyrl: Cyrillic
+  - id: price_currency
+    type: currency
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{
+		`<input class="place-lang" name="lang" type="text" id="lang" list="lang_list" placeholder="e.g. en, en-GB or zh-Hant" title="A BCP 47 language tag"><datalist id="lang_list">`,
+		`<option value="de">allemand</option>`,
+		`<select class="place-country" name="country" id="country" required>` + "\n" + `    <option value=""></option>`,
+		`<option value="DE">Allemagne</option>`,
+		`<option value="Latn">latin</option>` + "\n" + `    <option value="Cyrl">Cyrillic</option>` + "\n  </select>",
+		`<option value="EUR">EUR (€)</option>`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %s in HTML", expected)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// lookup.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lookup gives validation access to the application's records. It is passed in
+// at validation time (see ValidateContext) and is used to check elements marked
+// unique or with references, as well as by validators defined with DefineContext.
+type Lookup interface {
+	// Exists reports if a record with the primary id exists in the named model.
+	Exists(ctx context.Context, modelId string, id string) (bool, error)
+
+	// Find returns the primary ids of the records in the named model where the
+	// element holds the value.
+	Find(ctx context.Context, modelId string, elementId string, value string) ([]string, error)
+}
+
+// ValidateContext validates form data for a new record like ValidateMode then runs
+// the checks that need the application's records: unique elements, references and
+// the validators defined with DefineContext. A unique value already held by any record
+// is reported, use ValidateUpdateContext when the data updates an existing record. An
+// error is returned if a check couldn't be made, e.g. the lookup failed or the context
+// was cancelled.
+func (model *Model) ValidateContext(ctx context.Context, lookup Lookup, formData map[string]string, mode ValidationMode) (*ValidationResult, error) {
+	return model.ValidateUpdateContext(ctx, lookup, "", formData, mode)
+}
+
+// ValidateUpdateContext validates form data updating the record with the primary id
+// like ValidateContext. The record being updated can keep its own unique values, e.g.
+// when it is replaced (CreateMode) or patched (PartialMode).
+func (model *Model) ValidateUpdateContext(ctx context.Context, lookup Lookup, id string, formData map[string]string, mode ValidationMode) (*ValidationResult, error) {
+	data := map[string]interface{}{}
+	for k, v := range formData {
+		data[k] = v
+	}
+	return model.ValidateMapInterfaceUpdateContext(ctx, lookup, id, data, mode)
+}
+
+// ValidateMapInterfaceContext validates data for a new record like ValidateMapInterfaceMode
+// then runs the checks that need the application's records (see ValidateContext).
+func (model *Model) ValidateMapInterfaceContext(ctx context.Context, lookup Lookup, data map[string]interface{}, mode ValidationMode) (*ValidationResult, error) {
+	return model.ValidateMapInterfaceUpdateContext(ctx, lookup, "", data, mode)
+}
+
+// ValidateMapInterfaceUpdateContext validates data updating the record with the primary
+// id (see ValidateUpdateContext).
+func (model *Model) ValidateMapInterfaceUpdateContext(ctx context.Context, lookup Lookup, id string, data map[string]interface{}, mode ValidationMode) (*ValidationResult, error) {
+	result := model.validateData(data, mode)
+	if model == nil {
+		return result, nil
+	}
+	// Only values which passed the other checks are looked up.
+	failed := map[string]bool{}
+	for _, e := range result.Errors {
+		if e.Path != "" {
+			failed[strings.SplitN(strings.TrimPrefix(e.Path, "/"), "/", 2)[0]] = true
+		}
+	}
+	for _, elem := range model.Elements {
+		v, ok := data[elem.Id]
+		if !ok || failed[elem.Id] || isEmptyValue(v) {
+			continue
+		}
+		contextValidator := model.contextValidatorFor(elem.Type)
+		hasValidator := contextValidator != nil
+		if !hasValidator && !elem.Unique && elem.References == "" {
+			continue
+		}
+		if lookup == nil {
+			return result, fmt.Errorf("a lookup is required to validate %s.%s", model.Id, elem.Id)
+		}
+		path := jsonPointer("", elem.Id)
+		for i, val := range lookupValues(v) {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			itemPath := path
+			if _, isString := v.(string); !isString {
+				itemPath = jsonPointer(path, fmt.Sprintf("%d", i))
+			}
+			if hasValidator {
+				ok, err := contextValidator(ctx, lookup, elem, val)
+				if err != nil {
+					return result, err
+				}
+				if !ok {
+					result.AddPath(itemPath, elem, val, CodeInvalid, fmt.Sprintf("not a valid %s value", elem.Type))
+					continue
+				}
+			}
+			if elem.Unique {
+				ids, err := lookup.Find(ctx, model.Id, elem.Id, val)
+				if err != nil {
+					return result, err
+				}
+				for _, holder := range ids {
+					// NOTE: only the record being updated can keep its own value
+					if id == "" || holder != id {
+						result.AddPath(itemPath, elem, val, CodeNotUnique, fmt.Sprintf("%q is already used by %s", val, holder))
+						break
+					}
+				}
+			}
+			if elem.References != "" {
+				exists, err := lookup.Exists(ctx, elem.References, val)
+				if err != nil {
+					return result, err
+				}
+				if !exists {
+					result.AddPath(itemPath, elem, val, CodeNotFound, fmt.Sprintf("%q is not found in %s", val, elem.References))
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// lookupValues returns the submitted value(s) as strings.
+func lookupValues(v interface{}) []string {
+	switch val := v.(type) {
+	case []string:
+		return val
+	case []interface{}:
+		vals := []string{}
+		for _, item := range val {
+			vals = append(vals, stringifyValue(item))
+		}
+		return vals
+	}
+	return []string{stringifyValue(v)}
+}
+
+// MemoryLookup is an in-memory Lookup. It is intended for tests and small
+// applications. It is safe for concurrent use.
+type MemoryLookup struct {
+	mu sync.RWMutex
+	// records maps a model id to its records by primary id
+	records map[string]map[string]map[string]interface{}
+}
+
+// NewMemoryLookup returns an empty MemoryLookup.
+func NewMemoryLookup() *MemoryLookup {
+	return &MemoryLookup{
+		records: map[string]map[string]map[string]interface{}{},
+	}
+}
+
+// Set adds or replaces a record of the named model.
+func (l *MemoryLookup) Set(modelId string, id string, record map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.records[modelId]; !ok {
+		l.records[modelId] = map[string]map[string]interface{}{}
+	}
+	l.records[modelId][id] = record
+}
+
+// Delete removes a record of the named model.
+func (l *MemoryLookup) Delete(modelId string, id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if records, ok := l.records[modelId]; ok {
+		delete(records, id)
+	}
+}
+
+// Exists implements Lookup.
+func (l *MemoryLookup) Exists(ctx context.Context, modelId string, id string) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.records[modelId][id]
+	return ok, nil
+}
+
+// Find implements Lookup. A record holding a list of values matches if
+// any of the values match.
+func (l *MemoryLookup) Find(ctx context.Context, modelId string, elementId string, value string) ([]string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	ids := []string{}
+	for id, record := range l.records[modelId] {
+		v, ok := record[elementId]
+		if !ok {
+			continue
+		}
+		for _, val := range lookupValues(v) {
+			if val == value {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,156 @@
+// lookup_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestValidateContext tests unique elements, references and context validators.
+func TestValidateContext(t *testing.T) {
+	src := []byte(`id: article
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: doi
+    type: text
+    unique: true
+  - id: journal
+    type: text
+    references: journal
+  - id: reviewers
+    type: text
+    multiple: true
+    references: person
+  - id: grant
+    type: grant
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	model.Define("grant", GenerateText, ValidateText)
+	model.DefineContext("grant", func(ctx context.Context, lookup Lookup, elem *Element, val string) (bool, error) {
+		return lookup.Exists(ctx, "grant", val)
+	})
+
+	lookup := NewMemoryLookup()
+	lookup.Set("article", "one", map[string]interface{}{"id": "one", "doi": "10.1000/one"})
+	lookup.Set("journal", "apj", map[string]interface{}{"id": "apj"})
+	lookup.Set("person", "jane", map[string]interface{}{"id": "jane"})
+	lookup.Set("grant", "nsf-1", map[string]interface{}{"id": "nsf-1"})
+
+	ctx := context.Background()
+	formData := map[string]string{
+		"id":      "two",
+		"doi":     "10.1000/two",
+		"journal": "apj",
+		"grant":   "nsf-1",
+	}
+	result, err := model.ValidateContext(ctx, lookup, formData, CreateMode)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	// Updating record one with its own DOI is fine, whether it is patched or replaced
+	formData["id"] = "one"
+	formData["doi"] = "10.1000/one"
+	if result, _ = model.ValidateUpdateContext(ctx, lookup, "one", formData, PartialMode); !result.OK() {
+		t.Errorf("expected update of one to validate, got %s", result)
+	}
+	if result, _ = model.ValidateUpdateContext(ctx, lookup, "one", formData, CreateMode); !result.OK() {
+		t.Errorf("expected replacing one to validate, got %s", result)
+	}
+	// Creating a record reusing one's primary id clashes with its DOI
+	if result, _ = model.ValidateContext(ctx, lookup, formData, CreateMode); len(result.Errors) != 1 || result.Errors[0].Code != CodeNotUnique {
+		t.Errorf("expected create of one to fail with %q, got %s", CodeNotUnique, result)
+	}
+	// Updating another record with one's DOI clashes with it
+	formData["id"] = "two"
+	if result, _ = model.ValidateUpdateContext(ctx, lookup, "two", formData, CreateMode); len(result.Errors) != 1 || result.Errors[0].Code != CodeNotUnique {
+		t.Errorf("expected replacing two to fail with %q, got %s", CodeNotUnique, result)
+	}
+
+	data := map[string]interface{}{
+		"id":        "three",
+		"doi":       "10.1000/one",
+		"journal":   "nature",
+		"reviewers": []interface{}{"jane", "john"},
+		"grant":     "nih-2",
+	}
+	result, err = model.ValidateMapInterfaceContext(ctx, lookup, data, CreateMode)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := map[string]string{
+		"/doi":         CodeNotUnique,
+		"/journal":     CodeNotFound,
+		"/reviewers/1": CodeNotFound,
+		"/grant":       CodeInvalid,
+	}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d, %s", len(expected), len(result.Errors), result)
+	}
+	for _, e := range result.Errors {
+		if code, ok := expected[e.Path]; !ok || code != e.Code {
+			t.Errorf("unexpected error %s (%s)", e, e.Code)
+		}
+	}
+
+	if _, err = model.ValidateMapInterfaceContext(ctx, nil, data, CreateMode); err == nil {
+		t.Errorf("expected an error validating without a lookup")
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = model.ValidateMapInterfaceContext(cancelled, lookup, data, CreateMode); err == nil {
+		t.Errorf("expected an error validating with a cancelled context")
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{"doi text unique", "journal text references journal"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %s in SQL\n%s", expected, buf)
+		}
+	}
+}
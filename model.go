@@ -27,10 +27,11 @@
 package models
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -61,10 +62,24 @@ type Model struct {
 	// (required)
 	Elements []*Element `json:"elements,required" yaml:"elements,omitempty"`
 
+	// Models holds sub-models used to validate object values, an element refers to
+	// a sub-model by its id.
+	// (optional)
+	Models []*Model `json:"models,omitempty" yaml:"models,omitempty"`
+
+	// Rules holds cross field validation rules evaluated after the elements
+	// have been validated.
+	// (optional)
+	Rules []*Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+
 	// Title, A default title that will be pre-populated in the issue submission form.
 	// (optional) only there for compatibility with GitHub YAML Issue Templates
 	//Title string `json:"title,omitempty" yaml:"title,omitempty"`
 
+	// MaxBodySize is the request body size limit in bytes used by ValidateRequest.
+	// If zero DefaultMaxBodySize is used.
+	MaxBodySize int64 `json:"-" yaml:"-"`
+
 	// isChanged is an internal state used by the modeler to know when a model has changed
 	isChanged bool `json:"-" yaml:"-"`
 
@@ -73,100 +88,33 @@ type Model struct {
 	// registering the function then envoking render with the name registered.
 	renderer map[string]RenderFunc `json:"-" yaml:"-"`
 
-	// genElements holds a map to the "type" pointing to an element generator
-	genElements map[string]GenElementFunc `json:"-" yaml:"-"`
+	// types maps a type name to its definition (generator, validator, renderer mappings, etc.)
+	// for the types the model defines or overrides
+	types map[string]*TypeDefinition `json:"-" yaml:"-"`
 
-	// validators holds a list of validate function associated with types. Key is type name.
-	validators map[string]ValidateFunc `json:"-" yaml:"-"`
+	// registry holds the type registry the model inherits from, DefaultRegistry if nil
+	registry *TypeRegistry `json:"-" yaml:"-"`
 }
 
 // GenElementType takes an element type and returns an Element struct populated for that type and true or nil and false if type is not supported.
 func (model *Model) GenElementType(typeName string) (*Element, bool) {
-	if fn, ok := model.genElements[typeName]; ok {
-		return fn(), true
+	if def, ok := model.GetTypeDefinition(typeName); ok && def.Generate != nil {
+		return def.Generate(), true
 	}
 	return nil, false
 }
 
-// Validate form data expressed as map[string]string.
+// Validate form data expressed as map[string]string. It is a thin wrapper
+// around ValidateReport, use ValidateReport to find out why data failed.
 func (model *Model) Validate(formData map[string]string) bool {
-	ids := model.GetElementIds()
-	if len(ids) != len(formData) {
-		return false
-	}
-	for k, v := range formData {
-		if elem, ok := model.GetElementById(k); ok {
-			if validator, ok := model.validators[elem.Type]; ok {
-				if !validator(elem, v) {
-					if Debug {
-						log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q", elem.Id, elem.Type, v)
-					}
-					return false
-				}
-			} else {
-				return false
-			}
-		} else {
-			return false
-		}
-	}
-	return true
+	return model.ValidateReport(formData).OK()
 }
 
 // ValidateMapInterface normalizes the map inteface values before calling
-// the element's validator function.
+// the element's validator function. It is a thin wrapper around
+// ValidateMapInterfaceReport.
 func (model *Model) ValidateMapInterface(data map[string]interface{}) bool {
-	if model == nil {
-		if Debug {
-			log.Printf("model is nil, can't validate")
-		}
-		return false
-	}
-	ids := model.GetElementIds()
-	if len(ids) != len(data) {
-		if Debug {
-			log.Printf("DEBUG expected len(ids) %d, got len(data) %d", len(ids), len(data))
-		}
-		return false
-	}
-	for k, v := range data {
-		var val string
-		switch v.(type) {
-		case string:
-			val = v.(string)
-		case int:
-			val = fmt.Sprintf("%d", v)
-		case float64:
-			val = fmt.Sprintf("%f", v)
-		case json.Number:
-			val = fmt.Sprintf("%s", v)
-		case bool:
-			val = fmt.Sprintf("%t", v)
-		default:
-			val = fmt.Sprintf("%+v", v)
-		}
-		if elem, ok := model.GetElementById(k); ok {
-			if validator, ok := model.validators[elem.Type]; ok {
-				if !validator(elem, val) {
-					if Debug {
-						log.Printf("DEBUG failed to validate elem.Id %q, value %q", elem.Id, val)
-					}
-					return false
-				}
-			} else {
-				if Debug {
-					log.Printf("DEBUG failed to validate elem.Id %q, value %q, missing validator", elem.Id, val)
-				}
-				return false
-			}
-		} else {
-			if Debug {
-				log.Printf("DEBUG failed to validate elem.Id %q, value %q, missing elemnt %q", elem.Id, val, k)
-			}
-			return false
-		}
-	}
-	return true
+	return model.ValidateMapInterfaceReport(data).OK()
 }
 
 // HasChanges checks if the model's elements have changed
@@ -255,6 +203,65 @@ func (m *Model) GetGeneratedTypes() map[string]string {
 	return gt
 }
 
+// valueGenerators maps an element's generator to the func generating its values in Go. The
+// other generators (e.g. autoincrement, current_timestamp) are left to the database.
+var valueGenerators = map[string]func(*Element) (string, error){
+	"ark": MintElementARK,
+}
+
+// GenerateValues fills in the values missing from a record for elements whose generator
+// runs in Go, e.g. an element with the generator "ark" gets a newly minted ARK. An error
+// is returned if a value couldn't be generated.
+func (m *Model) GenerateValues(record map[string]interface{}) error {
+	for _, elem := range m.Elements {
+		genValue, ok := valueGenerators[elem.Generator]
+		if !ok {
+			continue
+		}
+		if v, ok := record[elem.Id]; ok && !isEmptyValue(v) {
+			continue
+		}
+		val, err := genValue(elem)
+		if err != nil {
+			return err
+		}
+		record[elem.Id] = val
+	}
+	return nil
+}
+
+// DeriveColumns returns the values of the derived columns (see TypeDefinition.DerivedColumns)
+// for the elements in a record, e.g. "published_earliest" and "published_latest" for an
+// edtf element "published". The column of an empty value is set to nil. An error is returned
+// if a value can't be derived.
+func (m *Model) DeriveColumns(record map[string]interface{}) (map[string]interface{}, error) {
+	columns := map[string]interface{}{}
+	for _, elem := range m.Elements {
+		v, ok := record[elem.Id]
+		if !ok || elem.IsMultiple() {
+			continue
+		}
+		for _, col := range m.resolveType(elem.Type).DerivedColumns {
+			if isEmptyValue(v) || col.Derive == nil {
+				columns[elem.Id+col.Suffix] = nil
+				continue
+			}
+			val := stringifyValue(v)
+			if obj, ok := v.(map[string]interface{}); ok {
+				if objectValue := m.objectValueFor(elem.Type); objectValue != nil {
+					val, _ = objectValue(obj)
+				}
+			}
+			derived, err := col.Derive(elem, val)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s can't be derived from %s, %s", elem.Id, col.Suffix, elem.Id, err)
+			}
+			columns[elem.Id+col.Suffix] = derived
+		}
+	}
+	return columns, nil
+}
+
 // GetElementById returns a Element from the model's .Elements.
 func (m *Model) GetElementById(id string) (*Element, bool) {
 	for _, elem := range m.Elements {
@@ -265,6 +272,35 @@ func (m *Model) GetElementById(id string) (*Element, bool) {
 	return nil, false
 }
 
+// GetModel returns a sub-model from the model's .Models.
+func (m *Model) GetModel(id string) (*Model, bool) {
+	for _, subModel := range m.Models {
+		if subModel.Id == id {
+			return subModel, true
+		}
+	}
+	return nil, false
+}
+
+// AddModel adds a sub-model used to validate object values. An element refers
+// to the sub-model using its id. If a sub-model with the same id exists it
+// is replaced.
+func (m *Model) AddModel(subModel *Model) error {
+	if subModel == nil || !IsValidVarname(subModel.Id) {
+		return fmt.Errorf("invalid sub-model")
+	}
+	for i, sm := range m.Models {
+		if sm.Id == subModel.Id {
+			m.Models[i] = subModel
+			m.isChanged = true
+			return nil
+		}
+	}
+	m.Models = append(m.Models, subModel)
+	m.isChanged = true
+	return nil
+}
+
 // NewModel, makes sure model id is valid, populates a Model with the identifier element providing
 // returns a *Model and error value.
 func NewModel(modelId string) (*Model, error) {
@@ -309,6 +345,15 @@ func (model *Model) Check(buf io.Writer) bool {
 				fmt.Fprintf(buf, "error for %s.%s\n", model.Id, e.Id)
 				ok = false
 			}
+			for _, cond := range []string{e.RequiredIf, e.ShowIf} {
+				if cond == "" {
+					continue
+				}
+				if err := checkCondition(model, cond); err != nil {
+					fmt.Fprintf(buf, "%s.%s %s\n", model.Id, e.Id, err)
+					ok = false
+				}
+			}
 			if e.IsObjectId {
 				if hasModelId == true {
 					fmt.Fprintf(buf, "duplicate model identifier element (%d) %s.%s\n", i, model.Id, e.Id)
@@ -321,6 +366,12 @@ func (model *Model) Check(buf io.Writer) bool {
 			fmt.Fprintf(buf, "missing required object identifier for model %s\n", model.Id)
 			ok = false
 		}
+		for _, rule := range model.Rules {
+			if err := rule.Check(model); err != nil {
+				fmt.Fprintf(buf, "%s\n", err)
+				ok = false
+			}
+		}
 		return ok
 	}
 	fmt.Fprintf(buf, "Missing elements for model %q\n", model.Id)
@@ -423,22 +474,171 @@ func (model *Model) Render(out io.Writer, name string) error {
 
 // IsSupportedElementType checks if the element type is supported by Newt, returns true if OK false is it is not
 func (model *Model) IsSupportedElementType(eType string) bool {
-	for sType, _ := range model.genElements {
-		if eType == sType {
-			return true
+	def, ok := model.GetTypeDefinition(eType)
+	return ok && def.Generate != nil
+}
+
+// Define takes a model and attaches a type definition (an element generator) and validator for the named type.
+// A normalizer can be included to convert the type's values into their canonical form (see Normalize).
+// The renderers map a type defined this way like its generated element's type, use DefineType to
+// set the mappings explicitly.
+func (model *Model) Define(typeName string, genElementFn GenElementFunc, validateFn ValidateFunc, normalizeFn ...NormalizeFunc) {
+	def := model.typeDefinition(typeName)
+	def.Generate = genElementFn
+	def.Validate = validateFn
+	if len(normalizeFn) > 0 && normalizeFn[0] != nil {
+		def.Normalize = normalizeFn[0]
+	}
+	if def.HTMLType == "" && genElementFn != nil {
+		if elem := genElementFn(); elem != nil && elem.Type != typeName {
+			def.HTMLType = elem.Type
 		}
 	}
-	return false
 }
 
-// Define takes a model and attaches a type definition (an element generator) and validator for the named type
-func (model *Model) Define(typeName string, genElementFn GenElementFunc, validateFn ValidateFunc) {
-	if model.genElements == nil {
-		model.genElements = map[string]GenElementFunc{}
+// DefineContext attaches a context aware validator to the named type. It is run by
+// ValidateContext after the type's validator accepted the value.
+func (model *Model) DefineContext(typeName string, validateFn ValidateContextFunc) {
+	model.typeDefinition(typeName).ValidateContext = validateFn
+}
+
+// DefineDecoder attaches a decode function to the named type. The decoder converts
+// the validated form value into a native Go value.
+func (model *Model) DefineDecoder(typeName string, decodeFn DecodeFunc) {
+	model.typeDefinition(typeName).Decode = decodeFn
+}
+
+// Decode converts form data into a map of native Go values using the decoders
+// defined for each element's type. Elements without a decoder are returned as
+// strings. Form data should be validated before it is decoded. An error is returned
+// if an id is not an element of the model or a value can't be decoded.
+func (model *Model) Decode(formData map[string]string) (map[string]interface{}, error) {
+	result := new(ValidationResult)
+	record := map[string]interface{}{}
+	for _, elem := range model.Elements {
+		v, ok := formData[elem.Id]
+		if !ok {
+			continue
+		}
+		decoder := model.decoderFor(elem.Type)
+		if decoder == nil {
+			record[elem.Id] = v
+			continue
+		}
+		val, err := decoder(elem, v)
+		if err != nil {
+			result.Add(elem, v, CodeInvalid, err.Error())
+			continue
+		}
+		record[elem.Id] = val
+	}
+	// NOTE: keys are sorted so the unexpected fields are listed in a stable order
+	keys := []string{}
+	for k := range formData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !model.HasElement(k) {
+			result.Errors = append(result.Errors, &ValidationError{
+				ElementId: k,
+				Value:     formData[k],
+				Code:      CodeUnexpectedField,
+				Message:   fmt.Sprintf("%q is not an element of %s", k, model.Id),
+			})
+		}
+	}
+	return record, result.Err()
+}
+
+// DecodeValues converts form data expressed as url.Values into a map of native Go
+// values. Multiple elements are decoded into a list of values. Like Decode it returns
+// an error if a field is not an element of the model or a value can't be decoded.
+func (model *Model) DecodeValues(values url.Values) (map[string]interface{}, error) {
+	return model.DecodeMapInterface(model.valuesToData(values))
+}
+
+// DecodeMapInterface converts data expressed as map[string]interface{} (e.g. parsed
+// JSON) into a map of native Go values using the same decoders as DecodeValues, so a
+// JSON number or boolean is decoded like its form value. Lists are decoded item by
+// item and objects using the sub-model named by the element. Like Decode it returns
+// an error if a field is not an element of the model or a value can't be decoded.
+func (model *Model) DecodeMapInterface(data map[string]interface{}) (map[string]interface{}, error) {
+	result := new(ValidationResult)
+	record := model.decodeObject(model, "", data, result)
+	return record, result.Err()
+}
+
+// decodeObject decodes the values of an object described by schema.
+func (model *Model) decodeObject(schema *Model, path string, data map[string]interface{}, result *ValidationResult) map[string]interface{} {
+	// NOTE: keys are sorted so the errors are listed in a stable order
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	record := map[string]interface{}{}
+	for _, k := range keys {
+		elem, ok := schema.GetElementById(k)
+		if !ok {
+			result.Errors = append(result.Errors, &ValidationError{
+				ElementId: k,
+				Value:     stringifyValue(data[k]),
+				Code:      CodeUnexpectedField,
+				Message:   fmt.Sprintf("%q is not an element of %s", k, schema.Id),
+			})
+			continue
+		}
+		if val, ok := model.decodeValue(elem, jsonPointer(path, k), data[k], result); ok {
+			record[k] = val
+		}
+	}
+	return record
+}
+
+// decodeValue decodes a value held by an element. It returns false if the value
+// couldn't be decoded.
+func (model *Model) decodeValue(elem *Element, path string, v interface{}, result *ValidationResult) (interface{}, bool) {
+	switch val := v.(type) {
+	case nil:
+		return nil, true
+	case []string:
+		items := []interface{}{}
+		for i, item := range val {
+			if decoded, ok := model.decodeValue(elem, jsonPointer(path, strconv.Itoa(i)), item, result); ok {
+				items = append(items, decoded)
+			}
+		}
+		return items, true
+	case []interface{}:
+		items := []interface{}{}
+		for i, item := range val {
+			if decoded, ok := model.decodeValue(elem, jsonPointer(path, strconv.Itoa(i)), item, result); ok {
+				items = append(items, decoded)
+			}
+		}
+		return items, true
+	case map[string]interface{}:
+		if objectValue := model.objectValueFor(elem.Type); objectValue != nil && elem.Model == "" {
+			// NOTE: a type can accept its values as JSON objects, e.g. a geo_point
+			if formValue, ok := objectValue(val); ok {
+				return model.decodeValue(elem, path, formValue, result)
+			}
+		}
+		if subModel, ok := model.GetModel(elem.Model); ok {
+			return model.decodeObject(subModel, path, val, result), true
+		}
+		return val, true
+	}
+	formValue := stringifyValue(v)
+	decoder := model.decoderFor(elem.Type)
+	if decoder == nil {
+		return formValue, true
 	}
-	model.genElements[typeName] = genElementFn
-	if model.validators == nil {
-		model.validators = map[string]ValidateFunc{}
+	decoded, err := decoder(elem, formValue)
+	if err != nil {
+		result.AddPath(path, elem, formValue, CodeInvalid, err.Error())
+		return nil, false
 	}
-	model.validators[typeName] = validateFn
+	return decoded, true
 }
@@ -28,22 +28,15 @@ package models
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	// 3rd Party packages
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
-func inList(l []string, s string) bool {
-	for _, val := range l {
-		if val == s {
-			return true
-		}
-	}
-	return false
-}
-
 // TestModel test a model's methods
 func TestModel(t *testing.T) {
 	m := new(Model)
@@ -331,3 +324,128 @@ func TestModelElements(t *testing.T) {
 		t.Errorf("expected zero model types, got %+v", modelTypes)
 	}
 }
+
+// TestDecode tests converting form data into native Go values.
+func TestDecode(t *testing.T) {
+	src := []byte(`id: test_decode
+description: This is a test of decoding form data
+elements:
+  - id: pid
+    type: uuid
+    is_primary_id: true
+  - id: title
+    type: text
+  - id: published
+    type: date
+  - id: updated
+    type: datetime-local
+  - id: pages
+    type: number
+  - id: price
+    type: number
+  - id: rating
+    type: range
+    attributes:
+      min: "0"
+      max: "10"
+  - id: peer_reviewed
+    type: checkbox
+  - id: draft
+    type: checkbox
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	formData := map[string]string{
+		"pid":           "01925416-3e1a-77a5-9cf5-7452554913c8",
+		"title":         "A title",
+		"published":     "2024-10-03",
+		"updated":       "2024-10-03T12:51:01",
+		"pages":         "312",
+		"price":         "12.95",
+		"rating":        "5",
+		"peer_reviewed": "on",
+	}
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	// NOTE: an unchecked checkbox isn't submitted by the browser but should still decode
+	formData["draft"] = ""
+	record, err := model.Decode(formData)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if pid, ok := record["pid"].(uuid.UUID); !ok || pid.String() != formData["pid"] {
+		t.Errorf("expected uuid.UUID for pid, got %T %+v", record["pid"], record["pid"])
+	}
+	if title, ok := record["title"].(string); !ok || title != "A title" {
+		t.Errorf("expected string for title, got %T %+v", record["title"], record["title"])
+	}
+	if published, ok := record["published"].(time.Time); !ok || published.Format("2006-01-02") != "2024-10-03" {
+		t.Errorf("expected time.Time for published, got %T %+v", record["published"], record["published"])
+	}
+	if updated, ok := record["updated"].(time.Time); !ok || updated.Second() != 1 {
+		t.Errorf("expected time.Time for updated, got %T %+v", record["updated"], record["updated"])
+	}
+	if pages, ok := record["pages"].(int64); !ok || pages != 312 {
+		t.Errorf("expected int64 for pages, got %T %+v", record["pages"], record["pages"])
+	}
+	if price, ok := record["price"].(float64); !ok || price != 12.95 {
+		t.Errorf("expected float64 for price, got %T %+v", record["price"], record["price"])
+	}
+	if reviewed, ok := record["peer_reviewed"].(bool); !ok || !reviewed {
+		t.Errorf("expected true for peer_reviewed, got %T %+v", record["peer_reviewed"], record["peer_reviewed"])
+	}
+	if draft, ok := record["draft"].(bool); !ok || draft {
+		t.Errorf("expected false for draft, got %T %+v", record["draft"], record["draft"])
+	}
+	formData["pages"] = "three hundred"
+	if _, err := model.Decode(formData); err == nil {
+		t.Errorf("expected an error decoding pages %q", formData["pages"])
+	}
+
+	// Unexpected fields are reported in a stable order
+	formData = map[string]string{"zulu": "z", "alpha": "a", "mike": "m", "bravo": "b"}
+	_, err = model.Decode(formData)
+	if err == nil {
+		t.Errorf("expected an error decoding %+v", formData)
+		t.FailNow()
+	}
+	msg := err.Error()
+	if strings.Index(msg, `"alpha"`) > strings.Index(msg, `"bravo"`) || strings.Index(msg, `"mike"`) > strings.Index(msg, `"zulu"`) {
+		t.Errorf("expected unexpected fields in sorted order, got %s", msg)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := model.Decode(formData); err == nil || err.Error() != msg {
+			t.Errorf("expected the same error decoding %+v, got %v", formData, err)
+			break
+		}
+	}
+}
+
+// TestValidateMapInterfacePrecision makes sure JSON numbers keep their precision.
+func TestValidateMapInterfacePrecision(t *testing.T) {
+	src := []byte(`id: test_precision
+description: This is a test of number precision
+elements:
+  - id: price
+    type: number
+    pattern: "[0-9]+\\.[0-9]{2}"
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	data := map[string]interface{}{
+		"price": 12.95,
+	}
+	if result := model.ValidateMapInterfaceReport(data); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", data, result)
+	}
+}
@@ -0,0 +1,391 @@
+// normalize.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	// 3rd Party packages
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeChange describes a value changed by Model.Normalize.
+type NormalizeChange struct {
+	// ElementId is the id of the element holding the value
+	ElementId string `json:"element_id" yaml:"element_id"`
+
+	// Path is a JSON pointer (RFC 6901) to the value, e.g. "/creators/0/orcid"
+	Path string `json:"path" yaml:"path"`
+
+	// From holds the value before it was normalized
+	From string `json:"from" yaml:"from"`
+
+	// To holds the normalized value
+	To string `json:"to" yaml:"to"`
+}
+
+// String describes the change.
+func (c *NormalizeChange) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Path, c.From, c.To)
+}
+
+// Normalize converts the string values of a record into their canonical form using the
+// normalizers defined for each element's type. Lists are normalized item by item
+// and objects using the element's sub-model. The record passed in isn't modified.
+// It returns the normalized record and the list of changes made.
+func (model *Model) Normalize(record map[string]interface{}) (map[string]interface{}, []*NormalizeChange) {
+	changes := []*NormalizeChange{}
+	return model.normalizeObject(model, "", record, &changes), changes
+}
+
+// normalizeObject normalizes the values of an object described by schema.
+func (model *Model) normalizeObject(schema *Model, path string, data map[string]interface{}, changes *[]*NormalizeChange) map[string]interface{} {
+	// NOTE: keys are sorted so the changes are listed in a stable order
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := map[string]interface{}{}
+	for _, k := range keys {
+		elem, ok := schema.GetElementById(k)
+		if !ok {
+			out[k] = data[k]
+			continue
+		}
+		out[k] = model.normalizeValue(elem, jsonPointer(path, k), data[k], changes)
+	}
+	return out
+}
+
+// normalizeValue normalizes a value held by an element.
+func (model *Model) normalizeValue(elem *Element, path string, v interface{}, changes *[]*NormalizeChange) interface{} {
+	switch val := v.(type) {
+	case string:
+		normalizeFn := model.normalizerFor(elem.Type)
+		if normalizeFn == nil {
+			return val
+		}
+		to := normalizeFn(elem, val)
+		if to != val {
+			*changes = append(*changes, &NormalizeChange{ElementId: elem.Id, Path: path, From: val, To: to})
+		}
+		return to
+	case []string:
+		items := []string{}
+		for i, item := range val {
+			items = append(items, model.normalizeValue(elem, jsonPointer(path, strconv.Itoa(i)), item, changes).(string))
+		}
+		return items
+	case []interface{}:
+		items := []interface{}{}
+		for i, item := range val {
+			items = append(items, model.normalizeValue(elem, jsonPointer(path, strconv.Itoa(i)), item, changes))
+		}
+		return items
+	case map[string]interface{}:
+		if subModel, ok := model.GetModel(elem.Model); ok {
+			return model.normalizeObject(subModel, path, val, changes)
+		}
+	}
+	return v
+}
+
+// NormalizeSpace trims the leading and trailing whitespace of a value.
+func NormalizeSpace(elem *Element, formValue string) string {
+	return strings.TrimSpace(formValue)
+}
+
+// NormalizeText trims the value and converts it to Unicode Normalization Form C (NFC).
+func NormalizeText(elem *Element, formValue string) string {
+	return norm.NFC.String(strings.TrimSpace(formValue))
+}
+
+// NormalizeEmail trims the value and lowercases the domain of the address. If the element's
+// "idn" attribute is set the domain is converted to its "punycode" or "unicode" form.
+func NormalizeEmail(elem *Element, formValue string) string {
+	formValue = strings.TrimSpace(formValue)
+	if _, ok := elem.Attributes["idn"]; ok {
+		// NOTE: the domain is converted to the element's idn form
+		if addr, err := parseEmailProfile(elem, formValue); err == nil {
+			formValue = addr
+		}
+	}
+	if i := strings.LastIndex(formValue, "@"); i > 0 {
+		return formValue[0:i] + strings.ToLower(formValue[i:])
+	}
+	return formValue
+}
+
+// NormalizeURL trims the value. If the element's "idn" attribute is set the host name is
+// converted to its "punycode" or "unicode" form, e.g. "https://bücher.example/" becomes
+// "https://xn--bcher-kva.example/".
+func NormalizeURL(elem *Element, formValue string) string {
+	formValue = strings.TrimSpace(formValue)
+	if _, ok := elem.Attributes["idn"]; !ok {
+		return formValue
+	}
+	u, err := parseURLProfile(elem, formValue)
+	if err != nil {
+		return formValue
+	}
+	val := u.String()
+	if elem.Attributes["idn"] == "unicode" && u.Hostname() != "" {
+		// NOTE: url.URL escapes a unicode host so it is swapped in after formatting
+		prefix := u.Scheme + "://"
+		if u.User != nil {
+			prefix += u.User.String() + "@"
+		}
+		if host, err := idna.Lookup.ToUnicode(u.Hostname()); err == nil && strings.HasPrefix(val, prefix+u.Hostname()) {
+			val = prefix + host + strings.TrimPrefix(val, prefix+u.Hostname())
+		}
+	}
+	return val
+}
+
+// NormalizeTel converts a phone number to E.164 form, e.g. "+16263954011". Like ValidateTel
+// numbers without a country code are taken to be in the element's region.
+func NormalizeTel(elem *Element, formValue string) string {
+	formValue = strings.TrimSpace(formValue)
+	num, err := phonenumbers.Parse(formValue, telRegion(elem))
+	if err != nil {
+		return formValue
+	}
+	return phonenumbers.Format(num, phonenumbers.E164)
+}
+
+// NormalizeORCID converts an ORCID into its hyphenated form, e.g. "https://orcid.org/0000000309006903"
+// becomes "0000-0003-0900-6903".
+func NormalizeORCID(elem *Element, formValue string) string {
+	val := strings.TrimSpace(formValue)
+	for _, prefix := range []string{"https://orcid.org/", "http://orcid.org/", "orcid.org/"} {
+		val = strings.TrimPrefix(val, prefix)
+	}
+	val = strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(val, "-", ""), " ", ""))
+	if len(val) != 16 {
+		return strings.TrimSpace(formValue)
+	}
+	return fmt.Sprintf("%s-%s-%s-%s", val[0:4], val[4:8], val[8:12], val[12:16])
+}
+
+// NormalizeROR converts a ROR into its bare id form, e.g. "https://ror.org/05dxps055" becomes "05dxps055".
+// Use NormalizeRORURL when defining the "ror" type to store the full URL instead.
+func NormalizeROR(elem *Element, formValue string) string {
+	val := trimIdentifierPrefix(strings.TrimSpace(formValue), rorPrefixes...)
+	return strings.ToLower(val)
+}
+
+// NormalizeRORURL converts a ROR into its URL form, e.g. "05dxps055" becomes "https://ror.org/05dxps055".
+func NormalizeRORURL(elem *Element, formValue string) string {
+	val := NormalizeROR(elem, formValue)
+	if val == "" {
+		return val
+	}
+	return "https://ror.org/" + val
+}
+
+// NormalizeDOI converts a DOI into its bare form with ASCII letters lowercased, e.g.
+// "https://doi.org/10.1000/ABC%23123" and "doi:10.1000/abc#123" become "10.1000/abc#123".
+// DOIs are case insensitive for ASCII letters. Use NormalizeDOIURL when defining the
+// "doi" type to store the doi.org URL instead.
+func NormalizeDOI(elem *Element, formValue string) string {
+	prefix, suffix, err := parseDOI(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return prefix + "/" + strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, suffix)
+}
+
+// NormalizeDOIURL converts a DOI into its doi.org URL form, e.g. "doi:10.1000/abc#123" becomes
+// "https://doi.org/10.1000/abc%23123".
+func NormalizeDOIURL(elem *Element, formValue string) string {
+	val := NormalizeDOI(elem, formValue)
+	if _, _, err := parseDOI(val); err != nil {
+		return val
+	}
+	var sb strings.Builder
+	sb.WriteString("https://doi.org/")
+	for _, b := range []byte(val) {
+		// NOTE: only the characters that would change the meaning of the URL are escaped
+		if b <= ' ' || b >= 0x7f || strings.IndexByte("\"#%<>?\\^`{|}", b) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		} else {
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
+
+// NormalizeARK converts an ARK into the "ark:NAAN/Name" form without a resolver URL, removing
+// the hyphens and trailing "/" or "." the ARK spec treats as insignificant, e.g.
+// "https://n2t.net/ark:/13030/tf5p3-0086k/" becomes "ark:13030/tf5p30086k". Use NormalizeARKURL
+// when defining the "ark" type to store the N2T resolver URL instead.
+func NormalizeARK(elem *Element, formValue string) string {
+	naan, name, err := parseARK(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	name = normalizeARKName(name)
+	if name == "" {
+		return strings.TrimSpace(formValue)
+	}
+	return "ark:" + naan + "/" + name
+}
+
+// NormalizeARKURL converts an ARK into its N2T resolver URL form, e.g. "ark:/13030/tf5p30086k"
+// becomes "https://n2t.net/ark:13030/tf5p30086k".
+func NormalizeARKURL(elem *Element, formValue string) string {
+	val := NormalizeARK(elem, formValue)
+	if !strings.HasPrefix(val, "ark:") {
+		return val
+	}
+	return "https://n2t.net/" + val
+}
+
+// NormalizeISBN converts an ISBN-10 into an ISBN-13 and hyphenates it, e.g. "0 306 40615 2"
+// becomes "978-0-306-40615-7". The hyphens are placed using the ranges loaded with
+// LoadISBNRanges. No ranges are built in, without them (or if the ranges don't cover
+// the ISBN) the ISBN-13 is returned as thirteen digits, e.g. "9780306406157". The
+// hyphens or spaces of the submitted value are ignored as they may be misplaced.
+func NormalizeISBN(elem *Element, formValue string) string {
+	val := strings.TrimSpace(formValue)
+	digits, err := parseISBN(val)
+	if err != nil {
+		return val
+	}
+	if len(digits) == 10 {
+		digits = "978" + digits[0:9]
+		digits += isbnCheckDigit13(digits)
+	}
+	if hyphenated, ok := hyphenateISBN13(digits); ok {
+		return hyphenated
+	}
+	return digits
+}
+
+// NormalizeISSN formats an ISSN as two hyphenated halves with an uppercase check digit,
+// e.g. "0317 847x" becomes "0317-847X".
+func NormalizeISSN(elem *Element, formValue string) string {
+	val := strings.TrimSpace(formValue)
+	if !ReISSN.MatchString(val) {
+		return val
+	}
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(val))
+	return digits[0:4] + "-" + digits[4:]
+}
+
+// NormalizePMID reduces a PubMed id to its digits, e.g. "PMID: 23193287" and
+// "https://pubmed.ncbi.nlm.nih.gov/23193287/" become "23193287".
+func NormalizePMID(elem *Element, formValue string) string {
+	val, err := parsePMID(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return val
+}
+
+// NormalizePMCID reduces a PubMed Central id to "PMC" followed by its digits keeping
+// the version, e.g. "https://pmc.ncbi.nlm.nih.gov/articles/pmc3531190.2/" becomes "PMC3531190.2".
+func NormalizePMCID(elem *Element, formValue string) string {
+	id, version, err := ParsePMCID(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	if version != "" {
+		return id + "." + version
+	}
+	return id
+}
+
+// NormalizeArXiv removes the "arXiv:" label or arxiv.org URL from an arXiv id keeping
+// the version, e.g. "https://arxiv.org/abs/2101.00001v2" becomes "2101.00001v2". Use
+// ParseArXiv to get the id and version as separate parts.
+func NormalizeArXiv(elem *Element, formValue string) string {
+	id, version, err := ParseArXiv(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return id + version
+}
+
+// NormalizeGeoPoint converts a point into "lat,lon", e.g. "POINT(-118.1253 34.1377)" becomes "34.1377,-118.1253".
+func NormalizeGeoPoint(elem *Element, formValue string) string {
+	p, err := ParseGeoPoint(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return p.String()
+}
+
+// NormalizeGeoBBox converts a bounding box into "south,west,north,east".
+func NormalizeGeoBBox(elem *Element, formValue string) string {
+	b, err := ParseGeoBBox(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return b.String()
+}
+
+// normalizeCode converts a code into its canonical form, the trimmed value is returned
+// if it isn't valid.
+func normalizeCode(formValue string, parse func(string) (string, error)) string {
+	code, err := parse(formValue)
+	if err != nil {
+		return strings.TrimSpace(formValue)
+	}
+	return code
+}
+
+// NormalizeLanguage converts a BCP 47 language tag into its canonical form, e.g. "en_us" becomes "en-US".
+func NormalizeLanguage(elem *Element, formValue string) string {
+	return normalizeCode(formValue, ParseLanguage)
+}
+
+// NormalizeCountry converts a country code into its ISO 3166-1 alpha-2 form, e.g. "usa" becomes "US".
+func NormalizeCountry(elem *Element, formValue string) string {
+	return normalizeCode(formValue, ParseCountry)
+}
+
+// NormalizeScript converts an ISO 15924 script code into title case, e.g. "latn" becomes "Latn".
+func NormalizeScript(elem *Element, formValue string) string {
+	return normalizeCode(formValue, ParseScript)
+}
+
+// NormalizeCurrency converts an ISO 4217 currency code into upper case, e.g. "eur" becomes "EUR".
+func NormalizeCurrency(elem *Element, formValue string) string {
+	return normalizeCode(formValue, ParseCurrency)
+}
@@ -0,0 +1,110 @@
+// normalize_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestNormalize tests values are normalized by type and the changes reported.
+func TestNormalize(t *testing.T) {
+	src := []byte(`id: test_normalize
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: title
+    type: text
+  - id: email
+    type: email
+  - id: phone
+    type: tel
+  - id: ror
+    type: ror
+  - id: creators
+    type: text
+    multiple: true
+    model: creator
+models:
+  - id: creator
+    elements:
+      - id: family
+        type: text
+      - id: orcid
+        type: orcid
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	record := map[string]interface{}{
+		"id":    "one",
+		"title": "  Café Society ",
+		"email": "Jane.Doe@Example.EDU",
+		"phone": "(626) 395-4011",
+		"ror":   "https://ror.org/05dxps055",
+		"creators": []interface{}{
+			map[string]interface{}{"family": "Doe", "orcid": "https://orcid.org/000000021694233x"},
+			map[string]interface{}{"family": "Jetson", "orcid": "0000-0002-1825-0097"},
+		},
+		"notes": " left alone ",
+	}
+	normalized, changes := model.Normalize(record)
+	expected := map[string]string{
+		"/title":            "Caf\u00e9 Society",
+		"/email":            "Jane.Doe@example.edu",
+		"/phone":            "+16263954011",
+		"/ror":              "05dxps055",
+		"/creators/0/orcid": "0000-0002-1694-233X",
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("expected %d changes, got %d, %+v", len(expected), len(changes), changes)
+	}
+	for _, change := range changes {
+		if to, ok := expected[change.Path]; !ok || to != change.To {
+			t.Errorf("unexpected change %s", change)
+		}
+	}
+	if normalized["email"] != "Jane.Doe@example.edu" || record["email"] != "Jane.Doe@Example.EDU" {
+		t.Errorf("expected a normalized copy of the record, got %+v and %+v", normalized, record)
+	}
+	if normalized["notes"] != " left alone " {
+		t.Errorf("expected values without an element to be left alone, got %q", normalized["notes"])
+	}
+
+	// The ROR URL form can be used by redefining the type.
+	model.Define("ror", GenerateROR, ValidateROR, NormalizeRORURL)
+	normalized, _ = model.Normalize(map[string]interface{}{"ror": "05DXPS055"})
+	if normalized["ror"] != "https://ror.org/05dxps055" {
+		t.Errorf("expected ROR URL, got %q", normalized["ror"])
+	}
+}
@@ -0,0 +1,230 @@
+// profiles.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	// 3rd Party packages
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/net/idna"
+)
+
+// The url, email and tel types can be made stricter through element attributes.
+//
+// url: "schemes" lists the schemes allowed (e.g. "https, http"), "require_host" set
+// to true rejects URLs without a host and "idn" set to "punycode" or "unicode" checks
+// an internationalized host name and normalizes it to that form.
+//
+// email: "strict" set to true only accepts a bare address (an addr-spec without a
+// display name or comments) whose domain is a valid, possibly internationalized,
+// domain name. "idn" normalizes the domain to its "punycode" or "unicode" form.
+//
+// tel: "region" is the ISO 3166-1 alpha-2 code used for numbers without a country
+// code ("US" by default). "number_type" set to "mobile" or "fixed_line" only accepts
+// valid numbers of that type.
+
+const (
+	// DefaultTelRegion is the region used for phone numbers without a country code.
+	// NOTE: It is "US" because Caltech Library is in the US.
+	DefaultTelRegion = "US"
+)
+
+var (
+	// ReURLScheme matches a URL scheme, e.g. "https"
+	ReURLScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*$`)
+)
+
+// isTrue checks if a boolean attribute is set, e.g. `require_host: "true"`.
+func isTrue(val string) bool {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "", "false", "off", "no", "0":
+		return false
+	}
+	return true
+}
+
+// attributeList splits an attribute holding a comma or space separated list.
+func attributeList(val string) []string {
+	return strings.FieldsFunc(val, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// idnDomain checks a domain name with the IDNA lookup rules returning it in its
+// "punycode" (ASCII) or "unicode" form.
+func idnDomain(domain string, form string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a valid domain name, %s", domain, err)
+	}
+	if strings.ToLower(form) == "unicode" {
+		return idna.Lookup.ToUnicode(ascii)
+	}
+	return ascii, nil
+}
+
+// parseURLProfile parses a URL checking it against the element's schemes, require_host
+// and idn attributes. The URL must be absolute, i.e. have a scheme. When idn is set the
+// host name is checked and converted to its punycode form (url.URL escapes a unicode host).
+func parseURLProfile(elem *Element, formValue string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(formValue))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", formValue)
+	}
+	if schemes := attributeList(elem.Attributes["schemes"]); len(schemes) > 0 {
+		allowed := false
+		for _, scheme := range schemes {
+			allowed = allowed || strings.EqualFold(scheme, u.Scheme)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("scheme %q is not one of %s", u.Scheme, strings.Join(schemes, ", "))
+		}
+	}
+	if isTrue(elem.Attributes["require_host"]) && u.Hostname() == "" {
+		return nil, fmt.Errorf("%q does not have a host", formValue)
+	}
+	if _, ok := elem.Attributes["idn"]; ok && u.Hostname() != "" && net.ParseIP(u.Hostname()) == nil {
+		host, err := idnDomain(u.Hostname(), "punycode")
+		if err != nil {
+			return nil, err
+		}
+		if port := u.Port(); port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		u.Host = host
+	}
+	return u, nil
+}
+
+// parseEmailProfile parses an email address checking it against the element's strict
+// attribute. It returns the address, with the domain converted when idn is set.
+func parseEmailProfile(elem *Element, formValue string) (string, error) {
+	formValue = strings.TrimSpace(formValue)
+	addr, err := mail.ParseAddress(formValue)
+	if err != nil {
+		return "", err
+	}
+	strict := isTrue(elem.Attributes["strict"])
+	if strict && (addr.Name != "" || addr.Address != formValue) {
+		return "", fmt.Errorf("%q is not a bare email address", formValue)
+	}
+	form, idn := elem.Attributes["idn"]
+	if !strict && !idn {
+		return addr.Address, nil
+	}
+	i := strings.LastIndex(addr.Address, "@")
+	local, domain := addr.Address[0:i], addr.Address[i+1:]
+	if strings.HasPrefix(domain, "[") {
+		if strict {
+			return "", fmt.Errorf("%q uses a domain literal", formValue)
+		}
+		return addr.Address, nil
+	}
+	if strict && !strings.Contains(strings.Trim(domain, "."), ".") {
+		return "", fmt.Errorf("%q is not a fully qualified domain name", domain)
+	}
+	if domain, err = idnDomain(domain, form); err != nil {
+		return "", err
+	}
+	return local + "@" + domain, nil
+}
+
+// telRegion returns the region of the element's region attribute or DefaultTelRegion.
+func telRegion(elem *Element) string {
+	if region := strings.TrimSpace(elem.Attributes["region"]); region != "" {
+		return strings.ToUpper(region)
+	}
+	return DefaultTelRegion
+}
+
+// parseTelProfile parses a phone number using the element's region attribute. If the
+// number_type attribute is set the number must be a valid number of that type.
+func parseTelProfile(elem *Element, formValue string) (*phonenumbers.PhoneNumber, error) {
+	num, err := phonenumbers.Parse(strings.TrimSpace(formValue), telRegion(elem))
+	if err != nil {
+		return nil, err
+	}
+	numberType := strings.ToLower(strings.TrimSpace(elem.Attributes["number_type"]))
+	if numberType == "" {
+		return num, nil
+	}
+	if !phonenumbers.IsValidNumber(num) {
+		return nil, fmt.Errorf("%q is not a valid phone number", formValue)
+	}
+	switch phonenumbers.GetNumberType(num) {
+	case phonenumbers.FIXED_LINE_OR_MOBILE:
+		return num, nil
+	case phonenumbers.MOBILE:
+		if numberType == "mobile" {
+			return num, nil
+		}
+	case phonenumbers.FIXED_LINE:
+		if numberType == "fixed_line" {
+			return num, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a %s number", formValue, strings.ReplaceAll(numberType, "_", " "))
+}
+
+// checkProfile checks the profile attributes of a url, email or tel element.
+func checkProfile(elem *Element) error {
+	switch strings.ToLower(elem.Type) {
+	case "url":
+		for _, scheme := range attributeList(elem.Attributes["schemes"]) {
+			if !ReURLScheme.MatchString(scheme) {
+				return fmt.Errorf("schemes holds an invalid scheme %q", scheme)
+			}
+		}
+	case "tel":
+		if region, ok := elem.Attributes["region"]; ok {
+			if !phonenumbers.GetSupportedRegions()[strings.ToUpper(strings.TrimSpace(region))] {
+				return fmt.Errorf("region %q is not a supported region", region)
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(elem.Attributes["number_type"])) {
+		case "", "mobile", "fixed_line":
+		default:
+			return fmt.Errorf("number_type %q should be mobile or fixed_line", elem.Attributes["number_type"])
+		}
+	}
+	switch strings.ToLower(elem.Type) {
+	case "url", "email":
+		if form, ok := elem.Attributes["idn"]; ok && form != "punycode" && form != "unicode" {
+			return fmt.Errorf("idn %q should be punycode or unicode", form)
+		}
+	}
+	return nil
+}
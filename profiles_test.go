@@ -0,0 +1,173 @@
+// profiles_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestURLProfile tests the schemes, require_host and idn attributes of a url element.
+func TestURLProfile(t *testing.T) {
+	elem := &Element{Id: "homepage", Type: "url", Attributes: map[string]string{}}
+	for _, val := range []string{"", "https://example.edu", "mailto:jane@example.edu", "urn:isbn:0451450523", "file:///tmp/x"} {
+		if !ValidateURL(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+	}
+	for _, val := range []string{"example.edu", "/about", "://example.edu", "http://exa mple.edu"} {
+		if ValidateURL(elem, val) {
+			t.Errorf("expected %q to fail validation", val)
+		}
+	}
+
+	elem.Attributes = map[string]string{"schemes": "https, http", "require_host": "true"}
+	for _, val := range []string{"https://example.edu/about", "HTTP://example.edu:8080", "https://[::1]/"} {
+		if !ValidateURL(elem, val) {
+			t.Errorf("expected %q to validate with schemes %q", val, elem.Attributes["schemes"])
+		}
+	}
+	for _, val := range []string{"ftp://example.edu", "mailto:jane@example.edu", "javascript:alert(1)", "https:///about", "https:example.edu"} {
+		if ValidateURL(elem, val) {
+			t.Errorf("expected %q to fail validation with schemes %q", val, elem.Attributes["schemes"])
+		}
+	}
+
+	elem.Attributes = map[string]string{"idn": "punycode"}
+	expected := map[string]string{
+		"https://bücher.example/katalog?q=1": "https://xn--bcher-kva.example/katalog?q=1",
+		"https://BÜCHER.example:8443/":       "https://xn--bcher-kva.example:8443/",
+		"https://xn--bcher-kva.example/":     "https://xn--bcher-kva.example/",
+		"https://192.168.0.1/":               "https://192.168.0.1/",
+	}
+	for val, e := range expected {
+		if !ValidateURL(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+		if got := NormalizeURL(elem, val); got != e {
+			t.Errorf("expected %q to normalize to %q, got %q", val, e, got)
+		}
+	}
+	for _, val := range []string{"https://exa_mple.edu/", "https://-example.edu/", "https://xn--a.example/"} {
+		if ValidateURL(elem, val) {
+			t.Errorf("expected %q to fail IDN validation", val)
+		}
+	}
+	elem.Attributes["idn"] = "unicode"
+	if got := NormalizeURL(elem, "https://xn--bcher-kva.example/"); got != "https://bücher.example/" {
+		t.Errorf("expected the unicode host name, got %q", got)
+	}
+}
+
+// TestEmailProfile tests the strict and idn attributes of an email element.
+func TestEmailProfile(t *testing.T) {
+	elem := &Element{Id: "email", Type: "email", Attributes: map[string]string{}}
+	for _, val := range []string{"jane@example.org", "Jane <jane@example.org>", "jane@localhost"} {
+		if !ValidateEmail(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+	}
+
+	elem.Attributes["strict"] = "true"
+	for _, val := range []string{"jane@example.org", " jane.doe+lib@example.org ", "jane@bücher.example", "jane@xn--bcher-kva.example"} {
+		if !ValidateEmail(elem, val) {
+			t.Errorf("expected %q to validate in strict mode", val)
+		}
+	}
+	for _, val := range []string{"Jane <jane@example.org>", "<jane@example.org>", "jane@example.org (Jane)", "jane@localhost",
+		"jane@[192.168.0.1]", "jane@exa_mple.org", "jane@-example.org", "jane"} {
+		if ValidateEmail(elem, val) {
+			t.Errorf("expected %q to fail validation in strict mode", val)
+		}
+	}
+
+	elem.Attributes["idn"] = "punycode"
+	if got := NormalizeEmail(elem, "Jane@Bücher.Example"); got != "Jane@xn--bcher-kva.example" {
+		t.Errorf("expected the punycode domain, got %q", got)
+	}
+	elem.Attributes["idn"] = "unicode"
+	if got := NormalizeEmail(elem, "jane@xn--bcher-kva.example"); got != "jane@bücher.example" {
+		t.Errorf("expected the unicode domain, got %q", got)
+	}
+}
+
+// TestTelProfile tests the region and number_type attributes of a tel element.
+func TestTelProfile(t *testing.T) {
+	elem := &Element{Id: "phone", Type: "tel", Attributes: map[string]string{}}
+	if got := NormalizeTel(elem, "(626) 395-4011"); got != "+16263954011" {
+		t.Errorf("expected a US number by default, got %q", got)
+	}
+	elem.Attributes["region"] = "gb"
+	expected := map[string]string{
+		"020 7946 0958":    "+442079460958",
+		"07400 123456":     "+447400123456",
+		"+1 626 395 4011":  "+16263954011",
+		"+44 20 7946 0958": "+442079460958",
+	}
+	for val, e := range expected {
+		if !ValidateTel(elem, val) {
+			t.Errorf("expected %q to validate", val)
+		}
+		if got := NormalizeTel(elem, val); got != e {
+			t.Errorf("expected %q to normalize to %q, got %q", val, e, got)
+		}
+	}
+
+	elem.Attributes["number_type"] = "mobile"
+	if !ValidateTel(elem, "07400 123456") || ValidateTel(elem, "020 7946 0958") || ValidateTel(elem, "07400 12") {
+		t.Errorf("expected only mobile numbers to validate")
+	}
+	elem.Attributes["number_type"] = "fixed_line"
+	if ValidateTel(elem, "07400 123456") || !ValidateTel(elem, "020 7946 0958") {
+		t.Errorf("expected only fixed line numbers to validate")
+	}
+	// US numbers can be either
+	elem.Attributes["region"] = "US"
+	if !ValidateTel(elem, "626-395-4011") {
+		t.Errorf("expected a US number to validate as a fixed line")
+	}
+}
+
+// TestCheckProfile tests reporting invalid profile attributes.
+func TestCheckProfile(t *testing.T) {
+	for _, elem := range []*Element{
+		{Id: "homepage", Type: "url", Attributes: map[string]string{"schemes": "https, 1http"}},
+		{Id: "homepage", Type: "url", Attributes: map[string]string{"idn": "ascii"}},
+		{Id: "email", Type: "email", Attributes: map[string]string{"idn": "yes"}},
+		{Id: "phone", Type: "tel", Attributes: map[string]string{"region": "ZZ"}},
+		{Id: "phone", Type: "tel", Attributes: map[string]string{"number_type": "pager"}},
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		if elem.Check(buf) {
+			t.Errorf("expected %+v to fail check", elem.Attributes)
+		}
+	}
+	elem := &Element{Id: "phone", Type: "tel", Attributes: map[string]string{"region": "gb", "number_type": "mobile"}}
+	if buf := bytes.NewBuffer([]byte{}); !elem.Check(buf) {
+		t.Errorf("expected %+v to pass check, %s", elem.Attributes, buf)
+	}
+}
@@ -48,6 +48,7 @@ func ModelToPythonClass(out io.Writer, model *Model) error {
 #
 
 `, model.Id, model.Description)
+	writeRuleComments(out, "#", model)
 
 	className := model.Id
 	if len(className) > 1 {
@@ -60,45 +61,45 @@ class %s:
 `, className, className)
 	for _, elem := range model.Elements {
 		varName := elem.Id
-		//varType := mapTypeToTypeScript(elem)
-		fmt.Fprintf(out, "    %s\n", varName)
+		varType := mapTypeToPython(model, elem)
+		fmt.Fprintf(out, "    %s: %s\n", varName, varType)
 	}
 	fmt.Fprintln(out, "\n    def __init__(self):")
 	for _, elem := range model.Elements {
 		varName := elem.Id
-		varDefault := mapTypeToPythonDefault(elem)
+		varDefault := mapTypeToPythonDefault(model, elem)
 		fmt.Fprintf(out, "        self.%s = %s\n", varName, varDefault)
 	}
 	return nil
 }
 
-func mapTypeToPythonDefault(elem *Element) string {
-	dTypes := map[string]string{
-		"date":           "",
-		"datetime-local": "",
-		"month":          "",
-		"color":          "",
-		"email":          "",
-		"number":         "0",
-		"range":          "[]",
-		"text":           "",
-		"tel":            "",
-		"time":           "",
-		"url":            "",
-		"checkbox":       "false",
-		"password":       "",
-		"radio":          "",
-		"textarea":       "",
-		"orcid":          "",
-		"isni":           "",
-		"uuid":           "",
-		"ror":            "",
+// mapTypeToPython returns the Python type of an element using the model's type definitions.
+func mapTypeToPython(model *Model, elem *Element) string {
+	varType := model.resolveType(elem.Type).PythonType
+	// A checkbox group holds the value of the checked option
+	if len(elem.Options) > 0 {
+		varType = "str"
 	}
-	if val, ok := dTypes[elem.Type]; ok {
-		if val == "" {
-			return `""`
-		}
-		return val
+	if elem.IsMultiple() {
+		return fmt.Sprintf("list[%s]", varType)
 	}
-	return ""
+	return varType
+}
+
+// mapTypeToPythonDefault returns the default value used to initialize an element's attribute.
+func mapTypeToPythonDefault(model *Model, elem *Element) string {
+	switch mapTypeToPython(model, elem) {
+	case "str":
+		return `""`
+	case "float":
+		return "0.0"
+	case "int":
+		return "0"
+	case "bool":
+		return "False"
+	}
+	if elem.IsMultiple() {
+		return "[]"
+	}
+	return "None"
 }
@@ -0,0 +1,198 @@
+// registry.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"sort"
+	"sync"
+)
+
+// Names of the type sets held by a TypeRegistry.
+const (
+	// HTML5Types holds the HTML5 input types, e.g. "date", "email"
+	HTML5Types = "html5"
+	// ScholarlyIdentifierTypes holds identifier types used in scholarly metadata, e.g. "orcid", "ror"
+	ScholarlyIdentifierTypes = "scholarly-identifiers"
+	// MetadataTypes holds types used in describing archival and library materials and datasets, e.g. "edtf", "geo_point"
+	MetadataTypes = "metadata"
+	// CustomTypes holds the types registered by an application
+	CustomTypes = "custom"
+)
+
+// TypeRegistry holds type definitions organized in named sets. When a type is defined
+// in more than one set the custom set takes precedence, followed by the scholarly
+// identifiers, the metadata types and then the HTML5 types. A TypeRegistry is safe
+// for concurrent use.
+type TypeRegistry struct {
+	mu sync.RWMutex
+
+	// sets maps a set name to its definitions by type name
+	sets map[string]map[string]*TypeDefinition
+
+	// order holds the set names in lookup order
+	order []string
+
+	// disabled holds the names of the sets skipped by Lookup
+	disabled map[string]bool
+}
+
+// DefaultRegistry is the registry models inherit their types from. It holds the
+// html5, scholarly-identifiers and metadata sets, RegisterType adds to its custom set.
+var DefaultRegistry = NewDefaultTypeRegistry()
+
+// NewDefaultTypeRegistry returns a registry holding the html5, scholarly-identifiers
+// and metadata sets. It can be used to give a model its own registry, e.g. one with the
+// scholarly-identifiers set disabled.
+func NewDefaultTypeRegistry() *TypeRegistry {
+	registry := NewTypeRegistry()
+	for _, def := range HTML5TypeDefinitions() {
+		registry.Register(HTML5Types, def)
+	}
+	for _, def := range ScholarlyIdentifierTypeDefinitions() {
+		registry.Register(ScholarlyIdentifierTypes, def)
+	}
+	for _, def := range MetadataTypeDefinitions() {
+		registry.Register(MetadataTypes, def)
+	}
+	return registry
+}
+
+// NewTypeRegistry returns an empty registry with the custom, scholarly-identifiers,
+// metadata and html5 sets.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		sets: map[string]map[string]*TypeDefinition{
+			CustomTypes:              {},
+			ScholarlyIdentifierTypes: {},
+			MetadataTypes:            {},
+			HTML5Types:               {},
+		},
+		order:    []string{CustomTypes, ScholarlyIdentifierTypes, MetadataTypes, HTML5Types},
+		disabled: map[string]bool{},
+	}
+}
+
+// Register adds or replaces a definition in the named set. A set that doesn't
+// exist is created and looked up after the existing sets.
+func (r *TypeRegistry) Register(setName string, def *TypeDefinition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	set, ok := r.sets[setName]
+	if !ok {
+		set = map[string]*TypeDefinition{}
+		r.sets[setName] = set
+		r.order = append(r.order, setName)
+	}
+	set[def.Name] = def
+}
+
+// Unregister removes a definition from the named set.
+func (r *TypeRegistry) Unregister(setName string, typeName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if set, ok := r.sets[setName]; ok {
+		delete(set, typeName)
+	}
+}
+
+// Lookup returns the definition of the named type.
+func (r *TypeRegistry) Lookup(typeName string) (*TypeDefinition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, setName := range r.order {
+		if r.disabled[setName] {
+			continue
+		}
+		if def, ok := r.sets[setName][typeName]; ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// EnableSet makes the types of the named set available again after DisableSet.
+func (r *TypeRegistry) EnableSet(setName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.disabled, setName)
+}
+
+// DisableSet makes the types of the named set unavailable as a group, e.g.
+// disabling ScholarlyIdentifierTypes removes orcid, ror, doi, etc. The set's
+// definitions are kept so it can be enabled again.
+func (r *TypeRegistry) DisableSet(setName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disabled[setName] = true
+}
+
+// IsSetEnabled checks if the types of the named set are available.
+func (r *TypeRegistry) IsSetEnabled(setName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.sets[setName]
+	return ok && !r.disabled[setName]
+}
+
+// SetNames returns the names of the registry's sets in lookup order.
+func (r *TypeRegistry) SetNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string{}, r.order...)
+}
+
+// TypeNames returns the sorted names of the types in the named set.
+func (r *TypeRegistry) TypeNames(setName string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := []string{}
+	for name := range r.sets[setName] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterType adds a definition to the custom set of the DefaultRegistry making
+// the type available to every model.
+func RegisterType(def *TypeDefinition) {
+	DefaultRegistry.Register(CustomTypes, def)
+}
+
+// TypeRegistry returns the registry the model inherits its types from.
+func (model *Model) TypeRegistry() *TypeRegistry {
+	if model.registry == nil {
+		return DefaultRegistry
+	}
+	return model.registry
+}
+
+// SetTypeRegistry sets the registry the model inherits its types from. Types
+// defined by the model itself still take precedence.
+func (model *Model) SetTypeRegistry(registry *TypeRegistry) {
+	model.registry = registry
+}
@@ -0,0 +1,156 @@
+// registry_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestTypeRegistry tests models inherit their types from a registry and can override them.
+func TestTypeRegistry(t *testing.T) {
+	src := []byte(`id: test_registry
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: published
+    type: date
+  - id: author
+    type: orcid
+  - id: badge
+    type: badge
+`)
+	// NOTE: no call to SetDefaultTypes
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	formData := map[string]string{
+		"id":        "one",
+		"published": "2024-10-03",
+		"author":    "0000-0003-0900-6903",
+	}
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate without setup, got %s", formData, result)
+	}
+	formData["published"] = "October 3rd"
+	if model.Validate(formData) {
+		t.Errorf("expected published %q to fail", formData["published"])
+	}
+	formData["published"] = "2024-10-03"
+
+	// Custom types registered globally are available to every model
+	if model.IsSupportedElementType("badge") {
+		t.Errorf("did not expect badge to be supported yet")
+	}
+	RegisterType(&TypeDefinition{
+		Name:     "badge",
+		Generate: GenerateText,
+		Validate: func(elem *Element, val string) bool { return val == "gold" || val == "silver" },
+	})
+	defer DefaultRegistry.Unregister(CustomTypes, "badge")
+	formData["badge"] = "bronze"
+	if model.Validate(formData) {
+		t.Errorf("expected badge %q to fail", formData["badge"])
+	}
+	formData["badge"] = "gold"
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+
+	// Overriding a type only changes the model
+	other := new(Model)
+	if err := yaml.Unmarshal(src, other); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	model.Define("date", GenerateDate, func(elem *Element, val string) bool { return val == "today" })
+	if model.Validate(formData) {
+		t.Errorf("expected the overridden date type to reject %q", formData["published"])
+	}
+	if !other.Validate(formData) {
+		t.Errorf("expected another model to keep the registry's date type")
+	}
+	if def, _ := DefaultRegistry.Lookup("date"); def.Validate(nil, "today") {
+		t.Errorf("expected the registry's date type to be unchanged")
+	}
+
+	// A model can use its own registry
+	registry := NewTypeRegistry()
+	for _, def := range HTML5TypeDefinitions() {
+		registry.Register(HTML5Types, def)
+	}
+	other.SetTypeRegistry(registry)
+	if other.IsSupportedElementType("orcid") || !other.IsSupportedElementType("date") {
+		t.Errorf("expected the model's registry to only hold the html5 types, got %+v", registry.SetNames())
+	}
+	for _, name := range []string{"orcid", "ror", "doi", "ark", "isbn", "issn", "pmid", "pmcid", "arxiv"} {
+		if names := DefaultRegistry.TypeNames(ScholarlyIdentifierTypes); !inList(names, name) {
+			t.Errorf("expected %s in %s, got %+v", name, ScholarlyIdentifierTypes, names)
+		}
+	}
+
+	// A type set can be disabled and enabled as a group
+	registry = NewDefaultTypeRegistry()
+	other.SetTypeRegistry(registry)
+	registry.DisableSet(ScholarlyIdentifierTypes)
+	if registry.IsSetEnabled(ScholarlyIdentifierTypes) || other.IsSupportedElementType("arxiv") || other.IsSupportedElementType("orcid") {
+		t.Errorf("expected the scholarly identifier types to be disabled")
+	}
+	if !other.IsSupportedElementType("date") || !model.IsSupportedElementType("arxiv") {
+		t.Errorf("expected disabling a set to only change the model's own registry")
+	}
+	registry.EnableSet(ScholarlyIdentifierTypes)
+	if !registry.IsSetEnabled(ScholarlyIdentifierTypes) || !other.IsSupportedElementType("arxiv") {
+		t.Errorf("expected the scholarly identifier types to be enabled")
+	}
+	if registry.IsSetEnabled("unknown") {
+		t.Errorf("did not expect an unknown set to be enabled")
+	}
+
+	// The registry is safe for concurrent use
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("concurrent_%d", i)
+			registry.Register(CustomTypes, &TypeDefinition{Name: name, Generate: GenerateText, Validate: ValidateText})
+			if _, ok := registry.Lookup(name); !ok {
+				t.Errorf("expected %s to be registered", name)
+			}
+			other.Validate(map[string]string{"id": name})
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,236 @@
+// render_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestOptionElements tests select and radio elements are validated and rendered using their options.
+func TestOptionElements(t *testing.T) {
+	src := []byte(`id: test_options
+description: This is a test of elements with options
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: resource_type
+    type: select
+    attributes:
+      required: true
+    label: Resource Type
+    options:
+      - article: Article
+      - thesis: Thesis
+      - book: Book & Chapters
+  - id: access
+    type: radio
+    label: Access
+    options:
+      - public: Public
+      - restricted: Restricted
+  - id: keywords
+    type: select
+    attributes:
+      multiple: true
+    options:
+      - astro: Astronomy
+      - bio: Biology
+  - id: series
+    type: select
+    options:
+      - 'R&D "Notes"': R&D Notes
+  - id: edition
+    type: radio
+    options:
+      - '1st & "best"': First
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	if !model.IsSupportedElementType("select") {
+		t.Errorf("expected select to be a supported type")
+	}
+	formData := map[string]string{
+		"id":            "one",
+		"resource_type": "thesis",
+		"access":        "public",
+		"keywords":      "bio",
+	}
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	formData["resource_type"] = "dataset"
+	formData["access"] = "secret"
+	if result := model.ValidateReport(formData); len(result.Errors) != 2 {
+		t.Errorf("expected resource_type and access to fail, got %s", result)
+	}
+	data := map[string]interface{}{
+		"id":            "two",
+		"resource_type": "book",
+		"keywords":      []interface{}{"astro", "chemistry"},
+	}
+	if result := model.ValidateMapInterfaceReport(data); len(result.Errors) != 1 || result.Errors[0].Path != "/keywords/1" {
+		t.Errorf("expected /keywords/1 to fail, got %s", result)
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	src = buf.Bytes()
+	for _, expected := range []string{
+		`<select class="test_options-resource_type" name="resource_type" id="resource_type" required>`,
+		`<option value="book">Book &amp; Chapters</option>`,
+		`<fieldset class="test_options-access" id="access"><legend>Access</legend>`,
+		`<input class="test_options-access" type="radio" name="access" id="access_1" value="restricted"> <label for="access_1">Restricted</label>`,
+		`<select class="test_options-keywords" name="keywords" id="keywords" multiple>`,
+		`<option value="R&amp;D &#34;Notes&#34;">R&amp;D Notes</option>`,
+		`<input class="test_options-edition" type="radio" name="edition" id="edition_0" value="1st &amp; &#34;best&#34;"> <label for="edition_0">First</label>`,
+	} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Errorf("expected %s in HTML\n%s", expected, src)
+		}
+	}
+
+	buf.Reset()
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	src = buf.Bytes()
+	for _, expected := range []string{
+		`resource_type text check (resource_type in ('article', 'thesis', 'book'))`,
+		`access text check (access in ('', 'public', 'restricted'))`,
+	} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Errorf("expected %s in SQL\n%s", expected, src)
+		}
+	}
+
+	buf.Reset()
+	if err := ModelToTypeScriptClass(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt := buf.String()
+	for _, expected := range []string{
+		`resource_type: "article" | "thesis" | "book";`,
+		`access: "public" | "restricted" = "public";`,
+		`keywords: ("astro" | "bio")[] = [];`,
+	} {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected %s in TypeScript\n%s", expected, txt)
+		}
+	}
+}
+
+// TestMultipleElements tests multiple elements are rendered as lists.
+func TestMultipleElements(t *testing.T) {
+	src := []byte(`id: test_multiple
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: topics
+    type: checkbox
+    multiple: true
+    options:
+      - astro: Astronomy
+      - bio: Biology
+  - id: keywords
+    type: text
+    multiple: true
+  - id: ratings
+    type: number
+    multiple: true
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt := buf.String()
+	expected := `<input class="test_multiple-topics" type="checkbox" name="topics" id="topics_1" value="bio"> <label for="topics_1">Biology</label>`
+	if !strings.Contains(txt, expected) {
+		t.Errorf("expected %s in HTML\n%s", expected, txt)
+	}
+
+	buf.Reset()
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt = buf.String()
+	expected = `keywords text check (json_valid(keywords) and json_type(keywords) = 'array')`
+	if !strings.Contains(txt, expected) {
+		t.Errorf("expected %s in SQL\n%s", expected, txt)
+	}
+
+	buf.Reset()
+	if err := ModelToTypeScriptClass(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt = buf.String()
+	for _, expected := range []string{
+		`topics: ("astro" | "bio")[] = [];`,
+		`keywords: string[] = [];`,
+		`ratings: number[] = [];`,
+	} {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected %s in TypeScript\n%s", expected, txt)
+		}
+	}
+
+	buf.Reset()
+	if err := ModelToPythonClass(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt = buf.String()
+	expected = `self.keywords = []`
+	if !strings.Contains(txt, expected) {
+		t.Errorf("expected %s in Python\n%s", expected, txt)
+	}
+}
@@ -0,0 +1,151 @@
+// request.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxBodySize is the request body size limit used by ValidateRequest
+// when the model's MaxBodySize isn't set (10 MiB).
+const DefaultMaxBodySize int64 = 10 << 20
+
+var (
+	// ErrUnsupportedMediaType is returned by ValidateRequest when the request's
+	// content type isn't urlencoded form data, multipart form data or JSON.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// ErrRequestTooLarge is returned by ValidateRequest when the request body
+	// exceeds the body size limit.
+	ErrRequestTooLarge = errors.New("request body too large")
+)
+
+// ValidateRequest reads the data submitted in an HTTP request and validates it against the
+// model. Request bodies encoded as "application/x-www-form-urlencoded", "multipart/form-data"
+// and "application/json" are supported. A GET or HEAD request validates the query string.
+// All required elements must be present (i.e. CreateMode).
+//
+// It returns the decoded record along with the validation report. Form and JSON data are
+// both decoded with the model's decoders (see DecodeMapInterface) so they give the same Go
+// values. An error is returned if the request body can't be read, i.e. it is too large
+// (ErrRequestTooLarge), the content type isn't supported (ErrUnsupportedMediaType) or the
+// body is malformed.
+//
+// ```
+//
+//	record, result, err := model.ValidateRequest(r)
+//	if err != nil {
+//	    http.Error(w, err.Error(), http.StatusBadRequest)
+//	    return
+//	}
+//	if !result.OK() {
+//	    w.WriteHeader(http.StatusUnprocessableEntity)
+//	    json.NewEncoder(w).Encode(result)
+//	    return
+//	}
+//
+// ```
+func (model *Model) ValidateRequest(r *http.Request) (map[string]interface{}, *ValidationResult, error) {
+	return model.ValidateRequestMode(r, CreateMode)
+}
+
+// ValidateRequestMode validates the data submitted in an HTTP request using the
+// validation mode provided, e.g. PartialMode for a PATCH request.
+func (model *Model) ValidateRequestMode(r *http.Request, mode ValidationMode) (map[string]interface{}, *ValidationResult, error) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return model.validateRequestValues(r.URL.Query(), mode)
+	}
+	contentType := r.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnsupportedMediaType, contentType)
+	}
+	limit := model.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, limit)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, nil, requestBodyError(err)
+		}
+		return model.validateRequestValues(r.PostForm, mode)
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(limit); err != nil {
+			return nil, nil, requestBodyError(err)
+		}
+		return model.validateRequestValues(url.Values(r.MultipartForm.Value), mode)
+	case "application/json":
+		data := map[string]interface{}{}
+		decoder := json.NewDecoder(r.Body)
+		// NOTE: json.Number retains the precision of the submitted number
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, nil, requestBodyError(err)
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, nil, fmt.Errorf("request body holds more than one JSON object")
+		}
+		return model.decodeRequestData(data, model.validateData(data, mode))
+	}
+	return nil, nil, fmt.Errorf("%w %q", ErrUnsupportedMediaType, mediaType)
+}
+
+// validateRequestValues validates form data from a request and decodes it.
+func (model *Model) validateRequestValues(values url.Values, mode ValidationMode) (map[string]interface{}, *ValidationResult, error) {
+	return model.decodeRequestData(model.valuesToData(values), model.ValidateValuesMode(values, mode))
+}
+
+// decodeRequestData decodes the validated data from a request so form and JSON
+// submissions give the same Go values.
+func (model *Model) decodeRequestData(data map[string]interface{}, result *ValidationResult) (map[string]interface{}, *ValidationResult, error) {
+	record, err := model.DecodeMapInterface(data)
+	if err != nil && result.OK() {
+		// NOTE: a decoder may be stricter than the type's validator
+		if failures, ok := err.(*ValidationResult); ok {
+			result.Errors = append(result.Errors, failures.Errors...)
+		}
+	}
+	return record, result, nil
+}
+
+// requestBodyError maps an error reading a request body to ErrRequestTooLarge
+// when the body size limit was exceeded.
+func requestBodyError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return fmt.Errorf("%w, %s", ErrRequestTooLarge, err)
+	}
+	return fmt.Errorf("failed to read request body, %s", err)
+}
@@ -0,0 +1,152 @@
+// request_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestValidateRequest tests validating urlencoded, multipart and JSON requests.
+func TestValidateRequest(t *testing.T) {
+	src := []byte(`id: test_request
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: pages
+    type: number
+    attributes:
+      min: 1
+  - id: keywords
+    type: text
+    multiple: true
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+
+	// urlencoded form data
+	values := url.Values{}
+	values.Set("id", "one")
+	values.Set("pages", "12")
+	values.Add("keywords", "stars")
+	values.Add("keywords", "planets")
+	r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	record, result, err := model.ValidateRequest(r)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !result.OK() {
+		t.Errorf("expected urlencoded request to validate, got %s", result)
+	}
+	if pages, ok := record["pages"].(int64); !ok || pages != 12 {
+		t.Errorf("expected pages to decode as 12, got %T %+v", record["pages"], record["pages"])
+	}
+	if keywords, ok := record["keywords"].([]interface{}); !ok || len(keywords) != 2 {
+		t.Errorf("expected two keywords, got %+v", record["keywords"])
+	}
+
+	// multipart form data
+	buf := bytes.NewBuffer([]byte{})
+	w := multipart.NewWriter(buf)
+	w.WriteField("id", "two")
+	w.WriteField("pages", "0")
+	w.Close()
+	r = httptest.NewRequest(http.MethodPost, "/records", buf)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	_, result, err = model.ValidateRequest(r)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Code != CodeOutOfRange {
+		t.Errorf("expected pages to be out of range, got %s", result)
+	}
+
+	// JSON
+	r = httptest.NewRequest(http.MethodPatch, "/records/three", strings.NewReader(`{"pages": 300, "keywords": ["comets"]}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	record, result, err = model.ValidateRequestMode(r, PartialMode)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !result.OK() {
+		t.Errorf("expected JSON request to validate, got %s", result)
+	}
+	// JSON is decoded to the same Go values as form data
+	if pages, ok := record["pages"].(int64); !ok || pages != 300 {
+		t.Errorf("expected pages to decode as 300, got %T %+v", record["pages"], record["pages"])
+	}
+	if keywords, ok := record["keywords"].([]interface{}); !ok || len(keywords) != 1 || keywords[0] != "comets" {
+		t.Errorf("expected one keyword, got %+v", record["keywords"])
+	}
+	r = httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(`{"id": "four"`))
+	r.Header.Set("Content-Type", "application/json")
+	if _, _, err = model.ValidateRequest(r); err == nil {
+		t.Errorf("expected an error for malformed JSON")
+	}
+
+	// query string
+	r = httptest.NewRequest(http.MethodGet, "/records?id=five&keywords=a&keywords=b", nil)
+	if _, result, err = model.ValidateRequest(r); err != nil || !result.OK() {
+		t.Errorf("expected query string to validate, got %s, %v", result, err)
+	}
+
+	// unsupported content type and body size limit
+	r = httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(`<record/>`))
+	r.Header.Set("Content-Type", "application/xml")
+	if _, _, err = model.ValidateRequest(r); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Errorf("expected ErrUnsupportedMediaType, got %v", err)
+	}
+	model.MaxBodySize = 16
+	r = httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, _, err = model.ValidateRequest(r); !errors.Is(err, ErrRequestTooLarge) {
+		t.Errorf("expected ErrRequestTooLarge, got %v", err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(`{"id": "six", "pages": 10}`))
+	r.Header.Set("Content-Type", "application/json")
+	if _, _, err = model.ValidateRequest(r); !errors.Is(err, ErrRequestTooLarge) {
+		t.Errorf("expected ErrRequestTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,817 @@
+// rules.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// Rule describes a cross field validation rule. The rule's expression is evaluated
+// against the submitted data after the elements have been validated. The data
+// is valid when the expression is true.
+//
+// Expressions are made from element ids, string literals (double or single quoted),
+// numbers, true and false combined with the comparison operators `==`, `!=`, `<`,
+// `<=`, `>` and `>=`, the logical operators `&&`, `||` and `!` and parenthesis. Values
+// are compared as numbers when both sides are numbers otherwise they are compared
+// as strings (this works for ISO 8601 dates). A value that wasn't submitted is an
+// empty string. The functions `empty(id)`, `present(id)` and `count(id)` (the number of values
+// held by an element) are also available.
+//
+// ```
+//
+//	rules:
+//	  - id: dates_in_order
+//	    expr: end_date == "" || end_date >= start_date
+//	    message: the end date must be on or after the start date
+//	  - id: embargo_when_restricted
+//	    expr: empty(embargo_until) || access == "restricted"
+//	  - id: has_contact
+//	    expr: present(email) || present(orcid)
+//
+// ```
+type Rule struct {
+	// Id identifies the rule, it is reported when the rule fails
+	Id string `json:"id" yaml:"id"`
+
+	// Expr holds the rule's expression
+	Expr string `json:"expr" yaml:"expr"`
+
+	// Message is reported when the rule fails (optional)
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// node holds the parsed expression, parsedExpr the expression it was parsed from
+	node       ruleNode
+	idents     []string
+	parsedExpr string
+}
+
+// UnmarshalYAML decodes a rule and parses its expression so it isn't parsed each
+// time the rule is evaluated. An expression that can't be parsed is reported by Check.
+func (r *Rule) UnmarshalYAML(value *yaml.Node) error {
+	type rule Rule
+	if err := value.Decode((*rule)(r)); err != nil {
+		return err
+	}
+	r.compile()
+	return nil
+}
+
+// UnmarshalJSON decodes a rule and parses its expression (see UnmarshalYAML).
+func (r *Rule) UnmarshalJSON(src []byte) error {
+	type rule Rule
+	if err := json.Unmarshal(src, (*rule)(r)); err != nil {
+		return err
+	}
+	r.compile()
+	return nil
+}
+
+// compile parses the rule's expression keeping the parsed expression on the rule.
+func (r *Rule) compile() {
+	node, idents, err := parseRule(r.Expr)
+	if err != nil {
+		r.node, r.idents, r.parsedExpr = nil, nil, ""
+		return
+	}
+	r.node, r.idents, r.parsedExpr = node, idents, r.Expr
+}
+
+// parsed returns the rule's parsed expression and the element ids it refers to. The
+// expression is only parsed again if it was changed after the rule was loaded.
+func (r *Rule) parsed() (ruleNode, []string, error) {
+	if r.node != nil && r.parsedExpr == r.Expr {
+		return r.node, r.idents, nil
+	}
+	return parseRule(r.Expr)
+}
+
+// ruleNode is a node in a parsed rule expression.
+type ruleNode interface {
+	// eval evaluates the node against the data, it returns a string, float64, bool or []interface{}
+	eval(data map[string]interface{}) (interface{}, error)
+	// sql renders the node as an SQLite 3 expression, the model is used to look up
+	// the columns of the elements referred to
+	sql(model *Model) string
+	// js renders the node as a JavaScript expression using the helpers
+	// written by conditionsToScript
+	js() string
+}
+
+type ruleLiteral struct {
+	val interface{}
+	src string
+}
+
+type ruleIdent struct {
+	id string
+}
+
+type ruleNot struct {
+	x ruleNode
+}
+
+type ruleBinary struct {
+	op   string
+	l, r ruleNode
+}
+
+type ruleCall struct {
+	fn string
+	x  *ruleIdent
+}
+
+func (n *ruleLiteral) eval(data map[string]interface{}) (interface{}, error) {
+	return n.val, nil
+}
+
+func (n *ruleLiteral) sql(model *Model) string {
+	switch val := n.val.(type) {
+	case string:
+		return sqlQuote(val)
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	}
+	return n.src
+}
+
+func (n *ruleLiteral) js() string {
+	switch val := n.val.(type) {
+	case string:
+		return strconv.Quote(val)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	// NOTE: numbers are compared by the c() helper so are passed as strings
+	return strconv.Quote(n.src)
+}
+
+func (n *ruleIdent) eval(data map[string]interface{}) (interface{}, error) {
+	switch val := data[n.id].(type) {
+	case nil:
+		return "", nil
+	case bool:
+		return val, nil
+	case []interface{}:
+		return val, nil
+	case []string:
+		items := []interface{}{}
+		for _, item := range val {
+			items = append(items, item)
+		}
+		return items, nil
+	case map[string]interface{}:
+		return nil, fmt.Errorf("%s holds an object", n.id)
+	default:
+		return stringifyValue(val), nil
+	}
+}
+
+func (n *ruleIdent) sql(model *Model) string {
+	// NOTE: a missing value is an empty string like it is in Go
+	return fmt.Sprintf("coalesce(%s, '')", n.id)
+}
+
+func (n *ruleIdent) js() string {
+	return fmt.Sprintf("v(%q)", n.id)
+}
+
+func (n *ruleNot) eval(data map[string]interface{}) (interface{}, error) {
+	val, err := n.x.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return !isTruthy(val), nil
+}
+
+func (n *ruleNot) sql(model *Model) string {
+	return fmt.Sprintf("not (%s)", sqlTruth(model, n.x))
+}
+
+func (n *ruleNot) js() string {
+	return fmt.Sprintf("!t(%s)", n.x.js())
+}
+
+func (n *ruleBinary) eval(data map[string]interface{}) (interface{}, error) {
+	l, err := n.l.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&":
+		if !isTruthy(l) {
+			return false, nil
+		}
+		r, err := n.r.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return isTruthy(r), nil
+	case "||":
+		if isTruthy(l) {
+			return true, nil
+		}
+		r, err := n.r.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return isTruthy(r), nil
+	}
+	r, err := n.r.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return compareValues(n.op, l, r)
+}
+
+func (n *ruleBinary) sql(model *Model) string {
+	op := n.op
+	switch n.op {
+	case "&&":
+		return fmt.Sprintf("(%s and %s)", sqlTruth(model, n.l), sqlTruth(model, n.r))
+	case "||":
+		return fmt.Sprintf("(%s or %s)", sqlTruth(model, n.l), sqlTruth(model, n.r))
+	case "==":
+		op = "="
+	case "!=":
+		op = "<>"
+	}
+	// NOTE: like compareValues the values are compared as numbers when both are
+	// numbers otherwise as strings, whatever the affinity of the columns.
+	asReal := fmt.Sprintf("%s %s %s", sqlAs(model, n.l, "real"), op, sqlAs(model, n.r, "real"))
+	asText := fmt.Sprintf("%s %s %s", sqlAs(model, n.l, "text"), op, sqlAs(model, n.r, "text"))
+	lNum, rNum := sqlIsNumber(model, n.l), sqlIsNumber(model, n.r)
+	switch {
+	case lNum == "0" || rNum == "0":
+		return asText
+	case lNum == "1" && rNum == "1":
+		return asReal
+	case lNum == "1":
+		return fmt.Sprintf("(case when %s then %s else %s end)", rNum, asReal, asText)
+	case rNum == "1":
+		return fmt.Sprintf("(case when %s then %s else %s end)", lNum, asReal, asText)
+	}
+	return fmt.Sprintf("(case when %s and %s then %s else %s end)", lNum, rNum, asReal, asText)
+}
+
+func (n *ruleBinary) js() string {
+	switch n.op {
+	case "&&", "||":
+		return fmt.Sprintf("(t(%s) %s t(%s))", n.l.js(), n.op, n.r.js())
+	}
+	return fmt.Sprintf("c(%q, %s, %s)", n.op, n.l.js(), n.r.js())
+}
+
+func (n *ruleCall) eval(data map[string]interface{}) (interface{}, error) {
+	val, err := n.x.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	count := 0
+	switch v := val.(type) {
+	case []interface{}:
+		count = len(v)
+	case string:
+		if v != "" {
+			count = 1
+		}
+	case bool:
+		count = 1
+	}
+	switch n.fn {
+	case "empty":
+		return count == 0, nil
+	case "present":
+		return count > 0, nil
+	}
+	return float64(count), nil
+}
+
+func (n *ruleCall) sql(model *Model) string {
+	// NOTE: only a multiple element's column holds a JSON array
+	count := fmt.Sprintf("(case when %s = '' then 0 else 1 end)", n.x.sql(model))
+	if elem, ok := model.GetElementById(n.x.id); ok && elem.IsMultiple() {
+		count = fmt.Sprintf("(case when %s = '' then 0 else json_array_length(%s) end)", n.x.sql(model), n.x.id)
+	}
+	switch n.fn {
+	case "empty":
+		return fmt.Sprintf("%s = 0", count)
+	case "present":
+		return fmt.Sprintf("%s > 0", count)
+	}
+	return count
+}
+
+func (n *ruleCall) js() string {
+	switch n.fn {
+	case "empty":
+		return fmt.Sprintf("(n(%s) === 0)", n.x.js())
+	case "present":
+		return fmt.Sprintf("(n(%s) > 0)", n.x.js())
+	}
+	return fmt.Sprintf("n(%s)", n.x.js())
+}
+
+// sqlTruth renders a node as an SQLite 3 expression that is true when the node
+// evaluates as true (see isTruthy).
+func sqlTruth(model *Model, node ruleNode) string {
+	switch n := node.(type) {
+	case *ruleLiteral:
+		if isTruthy(n.val) {
+			return "1"
+		}
+		return "0"
+	case *ruleIdent:
+		elem, ok := model.GetElementById(n.id)
+		if ok && elem.IsMultiple() {
+			return (&ruleCall{fn: "present", x: n}).sql(model)
+		}
+		// NOTE: a checkbox's column holds 1 or 0 for true or false
+		if ok && model.resolveType(elem.Type).SQLTypes["sqlite"] == "boolean" {
+			return fmt.Sprintf("lower(%s) not in ('', '0', 'false')", n.sql(model))
+		}
+		return fmt.Sprintf("lower(%s) not in ('', 'false')", n.sql(model))
+	}
+	return node.sql(model)
+}
+
+// sqlIsNumber renders an SQLite 3 expression that is true when the node's value
+// is a number. It returns "1" or "0" when it is known from the expression.
+func sqlIsNumber(model *Model, node ruleNode) string {
+	if n, ok := node.(*ruleLiteral); ok {
+		if _, err := strconv.ParseFloat(stringifyValue(n.val), 64); err == nil {
+			return "1"
+		}
+		return "0"
+	}
+	// NOTE: comparing with a real converts text holding a well formed number
+	val := node.sql(model)
+	return fmt.Sprintf("cast(%s as real) = %s", val, val)
+}
+
+// sqlAs renders a node as an SQLite 3 expression cast to the type, "real" or "text".
+func sqlAs(model *Model, node ruleNode, sqlType string) string {
+	if n, ok := node.(*ruleLiteral); ok {
+		if sqlType == "text" {
+			return sqlQuote(stringifyValue(n.val))
+		}
+		return n.sql(model)
+	}
+	return fmt.Sprintf("cast(%s as %s)", node.sql(model), sqlType)
+}
+
+// isTruthy works out if a value evaluates as true. Empty strings, "false",
+// zero and empty lists are false.
+func isTruthy(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case string:
+		return v != "" && strings.ToLower(v) != "false"
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// compareValues compares two values using the operator. Values are compared
+// as numbers when both are numbers otherwise they are compared as strings.
+func compareValues(op string, l interface{}, r interface{}) (bool, error) {
+	toString := func(val interface{}) (string, error) {
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("can't compare a list of values")
+	}
+	ls, err := toString(l)
+	if err != nil {
+		return false, err
+	}
+	rs, err := toString(r)
+	if err != nil {
+		return false, err
+	}
+	cmp := strings.Compare(ls, rs)
+	lf, lErr := strconv.ParseFloat(ls, 64)
+	rf, rErr := strconv.ParseFloat(rs, 64)
+	if lErr == nil && rErr == nil {
+		switch {
+		case lf < rf:
+			cmp = -1
+		case lf > rf:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// ruleToken is a lexical token of a rule expression.
+type ruleToken struct {
+	kind string // "ident", "string", "number", "op" or "eof"
+	text string
+	pos  int
+}
+
+// tokenizeRule splits a rule expression into tokens.
+func tokenizeRule(expr string) ([]*ruleToken, error) {
+	tokens := []*ruleToken{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(expr) && (expr[i] == '_' || (expr[i] >= 'a' && expr[i] <= 'z') || (expr[i] >= 'A' && expr[i] <= 'Z') || (expr[i] >= '0' && expr[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, &ruleToken{kind: "ident", text: expr[start:i], pos: start})
+		case (c >= '0' && c <= '9') || c == '-' || c == '.':
+			start := i
+			i++
+			for i < len(expr) && ((expr[i] >= '0' && expr[i] <= '9') || expr[i] == '.') {
+				i++
+			}
+			if _, err := strconv.ParseFloat(expr[start:i], 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", expr[start:i], start)
+			}
+			tokens = append(tokens, &ruleToken{kind: "number", text: expr[start:i], pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(expr) && expr[i] != c {
+				i++
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			tokens = append(tokens, &ruleToken{kind: "string", text: expr[start+1 : i], pos: start})
+			i++
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, &ruleToken{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, &ruleToken{kind: "eof", pos: len(expr)}), nil
+}
+
+// ruleParser is a recursive descent parser for rule expressions.
+type ruleParser struct {
+	tokens []*ruleToken
+	pos    int
+	idents []string
+}
+
+func (p *ruleParser) peek() *ruleToken {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() *ruleToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != "eof" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *ruleParser) expect(op string) error {
+	tok := p.next()
+	if tok.kind != "op" || tok.text != op {
+		return fmt.Errorf("expected %q at %d", op, tok.pos)
+	}
+	return nil
+}
+
+// parseOr parses `and ("||" and)*`
+func (p *ruleParser) parseOr() (ruleNode, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "op" && p.peek().text == "||" {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &ruleBinary{op: "||", l: l, r: r}
+	}
+	return l, nil
+}
+
+// parseAnd parses `not ("&&" not)*`
+func (p *ruleParser) parseAnd() (ruleNode, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "op" && p.peek().text == "&&" {
+		p.next()
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &ruleBinary{op: "&&", l: l, r: r}
+	}
+	return l, nil
+}
+
+// parseNot parses `"!" not | comparison`
+func (p *ruleParser) parseNot() (ruleNode, error) {
+	if p.peek().kind == "op" && p.peek().text == "!" {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleNot{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses `primary (comparison_op primary)?`
+func (p *ruleParser) parseComparison() (ruleNode, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == "op" {
+		switch tok.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			r, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &ruleBinary{op: tok.text, l: l, r: r}, nil
+		}
+	}
+	return l, nil
+}
+
+// parsePrimary parses literals, element ids, function calls and parenthesized expressions.
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case "string":
+		return &ruleLiteral{val: tok.text, src: tok.text}, nil
+	case "number":
+		f, _ := strconv.ParseFloat(tok.text, 64)
+		return &ruleLiteral{val: f, src: tok.text}, nil
+	case "ident":
+		switch tok.text {
+		case "true":
+			return &ruleLiteral{val: true, src: tok.text}, nil
+		case "false":
+			return &ruleLiteral{val: false, src: tok.text}, nil
+		case "empty", "present", "count":
+			if next := p.peek(); next.kind == "op" && next.text == "(" {
+				p.next()
+				arg := p.next()
+				if arg.kind != "ident" {
+					return nil, fmt.Errorf("%s expects an element id at %d", tok.text, arg.pos)
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				p.idents = append(p.idents, arg.text)
+				return &ruleCall{fn: tok.text, x: &ruleIdent{id: arg.text}}, nil
+			}
+		}
+		p.idents = append(p.idents, tok.text)
+		return &ruleIdent{id: tok.text}, nil
+	case "op":
+		if tok.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	if tok.kind == "eof" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+// parseRule parses a rule expression returning the root node and the element ids it refers to.
+func parseRule(expr string) (ruleNode, []string, error) {
+	tokens, err := tokenizeRule(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &ruleParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok := p.peek(); tok.kind != "eof" {
+		return nil, nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	return node, p.idents, nil
+}
+
+// Check makes sure the rule's expression can be parsed and only refers to the
+// model's elements.
+func (r *Rule) Check(model *Model) error {
+	if r.Id == "" {
+		return fmt.Errorf("rule is missing an id")
+	}
+	if err := checkCondition(model, r.Expr); err != nil {
+		return fmt.Errorf("rule %s, %s", r.Id, err)
+	}
+	r.compile()
+	return nil
+}
+
+// checkCondition makes sure a condition (e.g. an element's required_if) can be parsed
+// and only refers to the model's elements.
+func checkCondition(model *Model, expr string) error {
+	_, idents, err := parseRule(expr)
+	if err != nil {
+		return fmt.Errorf("condition %q, %s", expr, err)
+	}
+	for _, id := range idents {
+		if !model.HasElement(id) {
+			return fmt.Errorf("condition %q, %q is not an element of %s", expr, id, model.Id)
+		}
+	}
+	return nil
+}
+
+// evalCondition evaluates a condition against the data. It returns the
+// result, false if the condition couldn't be decided (e.g. an element it
+// refers to is missing from a partial update) and any error.
+func evalCondition(expr string, data map[string]interface{}, mode ValidationMode) (bool, bool, error) {
+	node, idents, err := parseRule(expr)
+	if err != nil {
+		return false, false, err
+	}
+	return evalNode(node, idents, data, mode)
+}
+
+// evalNode evaluates a parsed condition against the data (see evalCondition).
+func evalNode(node ruleNode, idents []string, data map[string]interface{}, mode ValidationMode) (bool, bool, error) {
+	if mode == PartialMode {
+		for _, id := range idents {
+			if _, ok := data[id]; !ok {
+				return false, false, nil
+			}
+		}
+	}
+	val, err := node.eval(data)
+	if err != nil {
+		return false, false, err
+	}
+	return isTruthy(val), true, nil
+}
+
+// Evaluate evaluates the rule's expression against the data returning true
+// if the data satisfies the rule.
+func (r *Rule) Evaluate(data map[string]interface{}) (bool, error) {
+	node, _, err := r.parsed()
+	if err != nil {
+		return false, err
+	}
+	val, err := node.eval(data)
+	if err != nil {
+		return false, err
+	}
+	return isTruthy(val), nil
+}
+
+// validateRules evaluates the model's rules against the data. In PartialMode a
+// rule is only evaluated when all the elements it refers to are present.
+func validateRules(schema *Model, path string, data map[string]interface{}, mode ValidationMode, result *ValidationResult) {
+	for _, rule := range schema.Rules {
+		node, idents, err := rule.parsed()
+		ok, decided := false, false
+		if err == nil {
+			ok, decided, err = evalNode(node, idents, data, mode)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, &ValidationError{
+				Rule:    rule.Id,
+				Value:   rule.Expr,
+				Code:    CodeInvalidRule,
+				Message: fmt.Sprintf("rule %s can't be evaluated, %s", rule.Id, err),
+				Path:    path,
+			})
+			continue
+		}
+		if decided && !ok {
+			msg := rule.Message
+			if msg == "" {
+				msg = fmt.Sprintf("rule %s failed, %s", rule.Id, rule.Expr)
+			}
+			result.Errors = append(result.Errors, &ValidationError{
+				Rule:    rule.Id,
+				Code:    CodeRuleFailed,
+				Message: msg,
+				Path:    path,
+			})
+		}
+	}
+}
+
+// ruleToSQL renders a rule as an SQLite 3 check constraint of the model's table.
+// The constraint evaluates like the rule does in Go, e.g. a NULL column is an empty
+// string and values are compared as numbers only when both are numbers.
+func ruleToSQL(model *Model, rule *Rule) (string, error) {
+	node, _, err := rule.parsed()
+	if err != nil {
+		return "", err
+	}
+	if IsValidVarname(rule.Id) {
+		return fmt.Sprintf("constraint %s check (%s)", rule.Id, sqlTruth(model, node)), nil
+	}
+	return fmt.Sprintf("check (%s)", sqlTruth(model, node)), nil
+}
+
+// writeRuleComments writes the model's rules as comments using the comment prefix
+// provided, e.g. "//" or "#".
+func writeRuleComments(out io.Writer, prefix string, model *Model) {
+	if len(model.Rules) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s Rules:\n", prefix)
+	for _, rule := range model.Rules {
+		if rule.Message != "" {
+			fmt.Fprintf(out, "%s   %s: %s (%s)\n", prefix, rule.Id, rule.Expr, rule.Message)
+		} else {
+			fmt.Fprintf(out, "%s   %s: %s\n", prefix, rule.Id, rule.Expr)
+		}
+	}
+	fmt.Fprintln(out, "")
+}
@@ -0,0 +1,366 @@
+// rules_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestRules tests cross field rules are evaluated and rendered.
+func TestRules(t *testing.T) {
+	src := []byte(`id: test_rules
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: start_date
+    type: date
+  - id: end_date
+    type: date
+  - id: access
+    type: text
+  - id: embargo_until
+    type: date
+  - id: email
+    type: email
+  - id: orcid
+    type: orcid
+  - id: pages
+    type: number
+rules:
+  - id: dates_in_order
+    expr: end_date == "" || end_date >= start_date
+    message: the end date must be on or after the start date
+  - id: embargo_when_restricted
+    expr: empty(embargo_until) || access == 'restricted'
+  - id: has_contact
+    expr: present(email) || present(orcid)
+  - id: enough_pages
+    expr: "!(pages < 10)"
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	buf := bytes.NewBuffer([]byte{})
+	if !model.Check(buf) {
+		t.Errorf("expected model to check, %s", buf)
+	}
+	formData := map[string]string{
+		"id":            "one",
+		"start_date":    "2024-01-05",
+		"end_date":      "2024-02-01",
+		"access":        "restricted",
+		"embargo_until": "2025-01-01",
+		"email":         "jane@example.edu",
+		"pages":         "100",
+	}
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	formData["end_date"] = "2023-12-31"
+	formData["access"] = "public"
+	delete(formData, "email")
+	// NOTE: 9 < 10 numerically but "9" > "10" as strings
+	formData["pages"] = "9"
+	result := model.ValidateReport(formData)
+	expected := []string{"dates_in_order", "embargo_when_restricted", "has_contact", "enough_pages"}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d, %s", len(expected), len(result.Errors), result)
+	}
+	for i, e := range result.Errors {
+		if i < len(expected) && (e.Rule != expected[i] || e.Code != CodeRuleFailed) {
+			t.Errorf("expected rule %s to fail, got %s (%s)", expected[i], e.Rule, e.Code)
+		}
+	}
+	if len(result.Errors) > 0 && result.Errors[0].Message != "the end date must be on or after the start date" {
+		t.Errorf("expected the rule's message, got %q", result.Errors[0].Message)
+	}
+
+	// Rules referring to elements missing from a partial update are skipped.
+	data := map[string]interface{}{
+		"id":    "one",
+		"email": "jane@example.edu",
+	}
+	if result := model.ValidateMapInterfaceMode(data, PartialMode); !result.OK() {
+		t.Errorf("expected partial update to validate, got %s", result)
+	}
+
+	buf.Reset()
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt := buf.String()
+	for _, expected := range []string{
+		`constraint embargo_when_restricted check (((case when coalesce(embargo_until, '') = '' then 0 else 1 end) = 0 or cast(coalesce(access, '') as text) = 'restricted'))`,
+		`constraint enough_pages check (not ((case when cast(coalesce(pages, '') as real) = coalesce(pages, '') then cast(coalesce(pages, '') as real) < 10 else cast(coalesce(pages, '') as text) < '10' end)))`,
+	} {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected %s in SQL\n%s", expected, txt)
+		}
+	}
+	buf.Reset()
+	if err := ModelToTypeScriptClass(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if expected := "//   has_contact: present(email) || present(orcid)\n"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected %q in TypeScript\n%s", expected, buf)
+	}
+
+	// The expressions are parsed when the model is loaded
+	for _, rule := range model.Rules {
+		if rule.node == nil {
+			t.Errorf("expected rule %s to be parsed when loaded", rule.Id)
+		}
+	}
+	rule := &Rule{Id: "changed"}
+	if err := json.Unmarshal([]byte(`{"id": "changed", "expr": "pages > 10"}`), rule); err != nil || rule.node == nil {
+		t.Errorf("expected rule to be parsed when decoded from JSON, %v", err)
+	}
+	rule.Expr = "pages < 10"
+	if ok, err := rule.Evaluate(map[string]interface{}{"pages": 5.0}); err != nil || !ok {
+		t.Errorf("expected the changed expression to be evaluated, got %t, %v", ok, err)
+	}
+
+	for _, expr := range []string{`end_date >=`, `(access == "public"`, `access = "public"`, `"open`, `unknown_id == 1`} {
+		rule := &Rule{Id: "bad", Expr: expr}
+		if err := rule.Check(model); err == nil {
+			t.Errorf("expected an error checking %q", expr)
+		}
+	}
+}
+
+// TestConditions tests required_if and show_if are enforced and rendered.
+func TestConditions(t *testing.T) {
+	src := []byte(`id: test_conditions
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: resource_type
+    type: select
+    options:
+      - article: Article
+      - thesis: Thesis
+  - id: advisor
+    type: text
+    required_if: resource_type == "thesis"
+  - id: degree
+    type: text
+    show_if: resource_type == "thesis"
+    attributes:
+      required: true
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	buf := bytes.NewBuffer([]byte{})
+	if !model.Check(buf) {
+		t.Errorf("expected model to check, %s", buf)
+	}
+	formData := map[string]string{
+		"id":            "one",
+		"resource_type": "article",
+	}
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	formData["resource_type"] = "thesis"
+	formData["advisor"] = ""
+	result := model.ValidateReport(formData)
+	if len(result.Errors) != 2 {
+		t.Errorf("expected advisor and degree to be missing, got %s", result)
+	}
+	for _, e := range result.Errors {
+		if e.Code != CodeMissing {
+			t.Errorf("expected %s to be missing, got %s", e.ElementId, e.Code)
+		}
+	}
+	formData["advisor"] = "Jane Doe"
+	formData["degree"] = "PhD"
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	// A partial update without resource_type can't decide the condition
+	data := map[string]interface{}{"id": "one", "advisor": ""}
+	if result := model.ValidateMapInterfaceMode(data, PartialMode); !result.OK() {
+		t.Errorf("expected partial update to validate, got %s", result)
+	}
+
+	buf.Reset()
+	if err := ModelToHTML(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	txt := buf.String()
+	for _, expected := range []string{
+		`{ id: "advisor", name: "advisor", required: () => c("==", v("resource_type"), "thesis") },`,
+		`{ id: "degree", name: "degree", show: () => c("==", v("resource_type"), "thesis") },`,
+		`const form = document.currentScript.closest("form");`,
+	} {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected %s in HTML\n%s", expected, txt)
+		}
+	}
+
+	elem, _ := model.GetElementById("advisor")
+	elem.ShowIf = `unknown == "x"`
+	buf.Reset()
+	if model.Check(buf) {
+		t.Errorf("expected model check to fail for show_if %q", elem.ShowIf)
+	}
+}
+
+// TestRulesInSQLite makes sure the check constraints rendered for the rules accept
+// and reject the same records as the rules evaluated in Go. It needs the sqlite3
+// command line program.
+func TestRulesInSQLite(t *testing.T) {
+	sqlite3, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 not found")
+	}
+	src := []byte(`id: test_sqlite_rules
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: title
+    type: text
+  - id: code
+    type: text
+  - id: pages
+    type: number
+  - id: agree
+    type: checkbox
+  - id: keywords
+    type: text
+    multiple: true
+  - id: start_date
+    type: date
+  - id: end_date
+    type: date
+rules:
+  - id: title_or_code
+    expr: title != "" || present(code)
+  - id: code_at_least_ten
+    expr: empty(code) || code >= 10
+  - id: enough_pages
+    expr: pages == "" || pages > 9
+  - id: agreed_with_keywords
+    expr: "!agree || count(keywords) >= 1"
+  - id: dates_in_order
+    expr: end_date == "" || end_date >= start_date
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	buf := bytes.NewBuffer([]byte{})
+	if err := ModelToSQLiteScheme(buf, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	scheme := buf.String()
+
+	// toSQL renders a value the way it is held by the table's columns
+	toSQL := func(v interface{}) string {
+		switch val := v.(type) {
+		case string:
+			return sqlQuote(val)
+		case bool:
+			if val {
+				return "1"
+			}
+			return "0"
+		case []interface{}:
+			src, _ := json.Marshal(val)
+			return sqlQuote(string(src))
+		}
+		return stringifyValue(v)
+	}
+	records := []map[string]interface{}{
+		{"id": "1", "title": "Stars"},
+		{"id": "2"},
+		{"id": "3", "title": ""},
+		{"id": "4", "code": "A"},
+		{"id": "5", "code": "9"},
+		{"id": "6", "code": "10"},
+		{"id": "7", "code": "100"},
+		{"id": "8", "title": "Stars", "pages": 10.0},
+		{"id": "9", "title": "Stars", "pages": 9.0},
+		{"id": "10", "title": "Stars", "pages": 100.0},
+		{"id": "11", "title": "Stars", "agree": true},
+		{"id": "12", "title": "Stars", "agree": true, "keywords": []interface{}{}},
+		{"id": "13", "title": "Stars", "agree": true, "keywords": []interface{}{"galaxy"}},
+		{"id": "14", "title": "Stars", "agree": false},
+		{"id": "15", "title": "Stars", "keywords": []interface{}{}},
+		{"id": "16", "title": "Stars", "start_date": "2024-01-05", "end_date": "2024-02-01"},
+		{"id": "17", "title": "Stars", "start_date": "2024-01-05", "end_date": "2023-12-31"},
+		{"id": "18", "title": "Stars", "start_date": "2024-01-05", "end_date": ""},
+	}
+	for _, record := range records {
+		expected := true
+		for _, rule := range model.Rules {
+			ok, err := rule.Evaluate(record)
+			if err != nil {
+				t.Errorf("rule %s can't be evaluated for %+v, %s", rule.Id, record, err)
+			}
+			expected = expected && ok
+		}
+		columns, values := []string{}, []string{}
+		for _, k := range getAttributeIds(map[string]string{"id": "", "title": "", "code": "", "pages": "", "agree": "", "keywords": "", "start_date": "", "end_date": ""}) {
+			if v, ok := record[k]; ok {
+				columns = append(columns, k)
+				values = append(values, toSQL(v))
+			}
+		}
+		stmt := fmt.Sprintf("insert into %s (%s) values (%s);\n", model.Id, strings.Join(columns, ", "), strings.Join(values, ", "))
+		cmd := exec.Command(sqlite3, "-bail", ":memory:")
+		cmd.Stdin = strings.NewReader(scheme + stmt)
+		out, err := cmd.CombinedOutput()
+		if got := err == nil; got != expected {
+			t.Errorf("expected %+v to be accepted %t by SQLite like Go, got %t\n%s%s", record, expected, got, stmt, out)
+		}
+	}
+}
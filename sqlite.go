@@ -42,7 +42,7 @@ func ModelToSQLiteScheme(out io.Writer, model *Model) error {
 	if model.Description != "" {
 		fmt.Fprintf(out, "-- %s\n", strings.ReplaceAll(model.Description, "\n", "\n-- "))
 	}
-	fmt.Fprintf(out, "create table %s if not exists (\n", model.Id)
+	fmt.Fprintf(out, "create table if not exists %s (\n", model.Id)
 	addNL := false
 	for i, elem := range model.Elements {
 		if !IsValidVarname(elem.Id) {
@@ -52,29 +52,14 @@ func ModelToSQLiteScheme(out io.Writer, model *Model) error {
 			fmt.Fprintf(out, ",\n")
 			addNL = true
 		}
-		//NOTE: Map HTML5 types to SQLite3 type
-		var columnType string
-		switch strings.ToLower(elem.Type) {
-		case "int":
-			columnType = "int"
-		case "integer":
-			columnType = "int"
-		case "float":
-			columnType = "real"
-		case "real":
-			columnType = "real"
-		case "numeric":
-			columnType = "num"
-		case "number":
-			columnType = "num"
-		case "date":
-			columnType = "text"
-		case "datetime-local":
-			columnType = "text"
-		case "checkbox":
-			columnType = "boolean"
-		default:
-			columnType = "text"
+		//NOTE: Map the element's type to a SQLite3 type using the type definitions
+		columnType := model.resolveType(elem.Type).SQLTypes["sqlite"]
+		if alias, ok := sqliteTypeAliases[strings.ToLower(elem.Type)]; ok {
+			columnType = alias
+		}
+		// NOTE: Multiple values are held as a JSON array
+		if elem.IsMultiple() {
+			columnType = fmt.Sprintf("text check (json_valid(%s) and json_type(%s) = 'array')", elem.Id, elem.Id)
 		}
 		if elem.Generator != "" {
 			switch elem.Generator {
@@ -97,7 +82,35 @@ func ModelToSQLiteScheme(out io.Writer, model *Model) error {
 		if elem.IsObjectId {
 			columnType = fmt.Sprintf(" %s primary key", columnType)
 		}
+		if elem.Unique && !elem.IsObjectId {
+			columnType = fmt.Sprintf("%s unique", columnType)
+		}
+		if elem.References != "" && !elem.IsMultiple() {
+			if !IsValidVarname(elem.References) {
+				return fmt.Errorf("element %s references a model that can't be used as a table name, %q", elem.Id, elem.References)
+			}
+			columnType = fmt.Sprintf("%s references %s", columnType, elem.References)
+		}
+		if check := optionsCheck(elem); check != "" {
+			columnType = fmt.Sprintf("%s %s", columnType, check)
+		}
 		fmt.Fprintf(out, "  %s %s", elem.Id, columnType)
+		// NOTE: Derived columns (e.g. the bounds of an EDTF date) follow the element's column
+		if !elem.IsMultiple() {
+			for _, col := range model.resolveType(elem.Type).DerivedColumns {
+				if sqlType, ok := col.SQLTypes["sqlite"]; ok {
+					fmt.Fprintf(out, ",\n  %s%s %s", elem.Id, col.Suffix, sqlType)
+				}
+			}
+		}
+	}
+	for _, rule := range model.Rules {
+		constraint, err := ruleToSQL(model, rule)
+		if err != nil {
+			return fmt.Errorf("rule %s can't be rendered, %s", rule.Id, err)
+		}
+		fmt.Fprintf(out, ",\n  %s", constraint)
+		addNL = true
 	}
 	if addNL {
 		fmt.Fprintf(out, "\n")
@@ -105,3 +118,38 @@ func ModelToSQLiteScheme(out io.Writer, model *Model) error {
 	fmt.Fprintf(out, ");\n")
 	return nil
 }
+
+// sqliteTypeAliases maps element types that name a SQL type directly to the column type.
+var sqliteTypeAliases = map[string]string{
+	"int":     "int",
+	"integer": "int",
+	"float":   "real",
+	"real":    "real",
+	"numeric": "num",
+}
+
+// sqlQuote returns a single quoted SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// optionsCheck returns a check constraint limiting a select or radio element's
+// column to its option values. If the element isn't required an empty string
+// is also allowed.
+func optionsCheck(elem *Element) string {
+	eType := strings.ToLower(elem.Type)
+	if (eType != "select" && eType != "radio") || len(elem.Options) == 0 {
+		return ""
+	}
+	if elem.IsMultiple() {
+		return ""
+	}
+	values := []string{}
+	if !elem.IsRequired() {
+		values = append(values, sqlQuote(""))
+	}
+	for _, val := range elem.OptionValues() {
+		values = append(values, sqlQuote(val))
+	}
+	return fmt.Sprintf("check (%s in (%s))", elem.Id, strings.Join(values, ", "))
+}
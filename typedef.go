@@ -0,0 +1,350 @@
+// typedef.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+// TypeDefinition describes a type an element can have. It holds the funcs used to
+// generate, validate, normalize and decode the type's values along with how the type
+// is expressed by each renderer. Mappings left empty are taken from the definition
+// of the type's HTML input type (e.g. an "orcid" is rendered like "text").
+type TypeDefinition struct {
+	// Name of the type, e.g. "date", "orcid"
+	Name string `json:"name" yaml:"name"`
+
+	// Generate sets up an element of the type
+	Generate GenElementFunc `json:"-" yaml:"-"`
+
+	// Validate checks a form value of the type
+	Validate ValidateFunc `json:"-" yaml:"-"`
+
+	// ValidateContext checks a form value using the application's records (optional)
+	ValidateContext ValidateContextFunc `json:"-" yaml:"-"`
+
+	// Normalize converts a form value into its canonical form (optional)
+	Normalize NormalizeFunc `json:"-" yaml:"-"`
+
+	// Decode converts a form value into a native Go value (optional)
+	Decode DecodeFunc `json:"-" yaml:"-"`
+
+	// ObjectValue converts a value submitted as a JSON object into a form value, e.g.
+	// {"lat": 34.1377, "lon": -118.1253} for a geo_point (optional). Without it an
+	// object is only accepted by an element referencing a model.
+	ObjectValue ObjectValueFunc `json:"-" yaml:"-"`
+
+	// HTMLType is the HTML input type used in a web form, e.g. "text" for an ORCID
+	HTMLType string `json:"html_type,omitempty" yaml:"html_type,omitempty"`
+
+	// SQLTypes maps an SQL dialect (e.g. "sqlite") to the column type
+	SQLTypes map[string]string `json:"sql_types,omitempty" yaml:"sql_types,omitempty"`
+
+	// TypeScriptType is the type used in a TypeScript class, e.g. "string"
+	TypeScriptType string `json:"typescript_type,omitempty" yaml:"typescript_type,omitempty"`
+
+	// PythonType is the type used in a Python class, e.g. "str"
+	PythonType string `json:"python_type,omitempty" yaml:"python_type,omitempty"`
+
+	// GoType is the Go type a decoded value has, e.g. "time.Time"
+	GoType string `json:"go_type,omitempty" yaml:"go_type,omitempty"`
+
+	// DerivedColumns are the columns holding values derived from an element's value, e.g.
+	// the earliest and latest bounds of an EDTF date. They follow the element's column.
+	DerivedColumns []*DerivedColumn `json:"derived_columns,omitempty" yaml:"derived_columns,omitempty"`
+}
+
+// DerivedColumn describes a column whose value is derived from an element's value,
+// see Model.DeriveColumns.
+type DerivedColumn struct {
+	// Suffix is added to the element's id to name the column, e.g. "_earliest"
+	Suffix string `json:"suffix" yaml:"suffix"`
+
+	// SQLTypes maps an SQL dialect (e.g. "sqlite") to the column type
+	SQLTypes map[string]string `json:"sql_types,omitempty" yaml:"sql_types,omitempty"`
+
+	// Derive calculates the column's value from the element's value, nil is stored as NULL
+	Derive func(*Element, string) (interface{}, error) `json:"-" yaml:"-"`
+}
+
+// merge fills the empty mappings of the definition from def.
+func (t *TypeDefinition) merge(def *TypeDefinition) {
+	if t.HTMLType == "" {
+		t.HTMLType = def.HTMLType
+	}
+	for dialect, sqlType := range def.SQLTypes {
+		if t.SQLTypes == nil {
+			t.SQLTypes = map[string]string{}
+		}
+		if _, ok := t.SQLTypes[dialect]; !ok {
+			t.SQLTypes[dialect] = sqlType
+		}
+	}
+	if t.TypeScriptType == "" {
+		t.TypeScriptType = def.TypeScriptType
+	}
+	if t.PythonType == "" {
+		t.PythonType = def.PythonType
+	}
+	if t.GoType == "" {
+		t.GoType = def.GoType
+	}
+	if len(t.DerivedColumns) == 0 {
+		t.DerivedColumns = def.DerivedColumns
+	}
+}
+
+// newTypeDefinition builds a TypeDefinition with the mappings most types share.
+func newTypeDefinition(name string, htmlType string, genElementFn GenElementFunc, validateFn ValidateFunc, normalizeFn NormalizeFunc) *TypeDefinition {
+	return &TypeDefinition{
+		Name:           name,
+		Generate:       genElementFn,
+		Validate:       validateFn,
+		Normalize:      normalizeFn,
+		HTMLType:       htmlType,
+		SQLTypes:       map[string]string{"sqlite": "text"},
+		TypeScriptType: "string",
+		PythonType:     "str",
+		GoType:         "string",
+	}
+}
+
+// DefaultTypeDefinitions returns the definitions of the types supported out of the box,
+// i.e. the HTML5 types followed by the scholarly identifier and metadata types.
+func DefaultTypeDefinitions() []*TypeDefinition {
+	definitions := append(HTML5TypeDefinitions(), ScholarlyIdentifierTypeDefinitions()...)
+	return append(definitions, MetadataTypeDefinitions()...)
+}
+
+// typeDefinitionList builds up a list of type definitions.
+type typeDefinitionList []*TypeDefinition
+
+// add appends a definition setting the decoder and the mappings that differ from a text value.
+func (l *typeDefinitionList) add(def *TypeDefinition, decodeFn DecodeFunc, sqlType string, tsType string, pyType string, goType string) {
+	def.Decode = decodeFn
+	if sqlType != "" {
+		def.SQLTypes["sqlite"] = sqlType
+	}
+	if tsType != "" {
+		def.TypeScriptType = tsType
+	}
+	if pyType != "" {
+		def.PythonType = pyType
+	}
+	if goType != "" {
+		def.GoType = goType
+	}
+	*l = append(*l, def)
+}
+
+// HTML5TypeDefinitions returns the definitions of the HTML5 input types.
+func HTML5TypeDefinitions() []*TypeDefinition {
+	definitions := typeDefinitionList{}
+	definitions.add(newTypeDefinition("date", "date", GenerateDate, ValidateDate, NormalizeSpace), DecodeDate, "", "", "", "time.Time")
+	definitions.add(newTypeDefinition("datetime-local", "datetime-local", GenerateDateTimeLocal, ValidateDateTimeLocal, NormalizeSpace), DecodeDateTimeLocal, "", "", "", "time.Time")
+	definitions.add(newTypeDefinition("month", "month", GenerateMonth, ValidateMonth, NormalizeSpace), DecodeMonth, "", "", "", "time.Time")
+	definitions.add(newTypeDefinition("color", "color", GenerateColor, ValidateColor, NormalizeSpace), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("email", "email", GenerateEmail, ValidateEmail, NormalizeEmail), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("text", "text", GenerateText, ValidateText, NormalizeText), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("number", "number", GenerateNumber, ValidateNumber, NormalizeSpace), DecodeNumber, "num", "number", "float", "float64")
+	definitions.add(newTypeDefinition("range", "range", GenerateRange, ValidateRange, NormalizeSpace), DecodeNumber, "num", "number", "float", "float64")
+	definitions.add(newTypeDefinition("tel", "tel", GenerateTel, ValidateTel, NormalizeTel), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("time", "time", GenerateTime, ValidateTime, NormalizeSpace), DecodeTime, "", "", "", "time.Time")
+	definitions.add(newTypeDefinition("url", "url", GenerateURL, ValidateURL, NormalizeURL), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("checkbox", "checkbox", GenerateCheckbox, ValidateCheckbox, nil), DecodeCheckbox, "boolean", "boolean", "bool", "bool")
+	definitions.add(newTypeDefinition("password", "password", GeneratePassword, ValidatePassword, nil), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("radio", "radio", GenerateRadio, ValidateRadio, nil), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("select", "select", GenerateSelect, ValidateSelect, nil), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("textarea", "textarea", GenerateTextarea, ValidateTextarea, NormalizeText), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("week", "week", GenerateWeek, ValidateWeek, NormalizeSpace), DecodeWeek, "", "", "", "time.Time")
+	return definitions
+}
+
+// ScholarlyIdentifierTypeDefinitions returns the definitions of the identifier types
+// used in scholarly metadata, e.g. ORCID, ROR and DOI.
+func ScholarlyIdentifierTypeDefinitions() []*TypeDefinition {
+	definitions := typeDefinitionList{}
+	definitions.add(newTypeDefinition("orcid", "text", GenerateORCID, ValidateORCID, NormalizeORCID), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("isni", "text", GenerateISNI, ValidateISNI, NormalizeSpace), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("uuid", "text", GenerateUUID, ValidateUUID, NormalizeSpace), DecodeUUID, "", "", "", "uuid.UUID")
+	definitions.add(newTypeDefinition("ror", "text", GenerateROR, ValidateROR, NormalizeROR), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("doi", "text", GenerateDOI, ValidateDOI, NormalizeDOI), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("ark", "text", GenerateARK, ValidateARK, NormalizeARK), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("isbn", "text", GenerateISBN, ValidateISBN, NormalizeISBN), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("issn", "text", GenerateISSN, ValidateISSN, NormalizeISSN), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("pmid", "text", GeneratePMID, ValidatePMID, NormalizePMID), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("pmcid", "text", GeneratePMCID, ValidatePMCID, NormalizePMCID), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("arxiv", "text", GenerateArXiv, ValidateArXiv, NormalizeArXiv), nil, "", "", "", "")
+	return definitions
+}
+
+// MetadataTypeDefinitions returns the definitions of the types used in describing
+// archival and library materials and datasets, e.g. EDTF dates, geographic points and
+// language codes.
+func MetadataTypeDefinitions() []*TypeDefinition {
+	definitions := typeDefinitionList{}
+	edtf := newTypeDefinition("edtf", "text", GenerateEDTF, ValidateEDTF, NormalizeSpace)
+	edtf.DerivedColumns = []*DerivedColumn{
+		{Suffix: "_earliest", SQLTypes: map[string]string{"sqlite": "text"}, Derive: DeriveEDTFEarliest},
+		{Suffix: "_latest", SQLTypes: map[string]string{"sqlite": "text"}, Derive: DeriveEDTFLatest},
+	}
+	definitions.add(edtf, nil, "", "", "", "")
+	geoPoint := newTypeDefinition("geo_point", "geo_point", GenerateGeoPoint, ValidateGeoPoint, NormalizeGeoPoint)
+	geoPoint.DerivedColumns = geoPointColumns()
+	geoPoint.ObjectValue = GeoPointObjectValue
+	definitions.add(geoPoint, DecodeGeoPoint, "", "{lat:number, lon:number}", "dict[str, float]", "models.GeoPoint")
+	geoBBox := newTypeDefinition("geo_bbox", "geo_bbox", GenerateGeoBBox, ValidateGeoBBox, NormalizeGeoBBox)
+	geoBBox.DerivedColumns = geoBBoxColumns()
+	geoBBox.ObjectValue = GeoBBoxObjectValue
+	definitions.add(geoBBox, DecodeGeoBBox, "", "{sw:{lat:number, lon:number}, ne:{lat:number, lon:number}}", "dict[str, dict[str, float]]", "models.GeoBBox")
+	definitions.add(newTypeDefinition("language", "language", GenerateLanguage, ValidateLanguage, NormalizeLanguage), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("country", "country", GenerateCountry, ValidateCountry, NormalizeCountry), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("script", "script", GenerateScript, ValidateScript, NormalizeScript), nil, "", "", "", "")
+	definitions.add(newTypeDefinition("currency", "currency", GenerateCurrency, ValidateCurrency, NormalizeCurrency), nil, "", "", "", "")
+	return definitions
+}
+
+// builtinTypes holds the default type definitions by name. Renderers fall back to
+// these when a model doesn't define a type.
+var builtinTypes = map[string]*TypeDefinition{}
+
+func init() {
+	for _, def := range DefaultTypeDefinitions() {
+		builtinTypes[def.Name] = def
+	}
+}
+
+// DefineType adds or replaces the definition of a type.
+func (model *Model) DefineType(def *TypeDefinition) {
+	if model.types == nil {
+		model.types = map[string]*TypeDefinition{}
+	}
+	model.types[def.Name] = def
+}
+
+// GetTypeDefinition returns the definition of the named type. Types defined by the model
+// take precedence over those in the model's type registry (DefaultRegistry unless set with
+// SetTypeRegistry). A definition from a registry is shared and must not be modified.
+func (model *Model) GetTypeDefinition(typeName string) (*TypeDefinition, bool) {
+	if model == nil {
+		return DefaultRegistry.Lookup(typeName)
+	}
+	if def, ok := model.types[typeName]; ok {
+		return def, true
+	}
+	return model.TypeRegistry().Lookup(typeName)
+}
+
+// typeDefinition returns the model's own definition of the named type. A definition
+// inherited from the registry is copied so it can be overridden.
+func (model *Model) typeDefinition(typeName string) *TypeDefinition {
+	if def, ok := model.types[typeName]; ok {
+		return def
+	}
+	def := &TypeDefinition{Name: typeName}
+	if inherited, ok := model.TypeRegistry().Lookup(typeName); ok {
+		def = inherited.clone()
+	}
+	model.DefineType(def)
+	return def
+}
+
+// clone returns a copy of the definition.
+func (t *TypeDefinition) clone() *TypeDefinition {
+	def := *t
+	def.SQLTypes = map[string]string{}
+	for k, v := range t.SQLTypes {
+		def.SQLTypes[k] = v
+	}
+	return &def
+}
+
+// resolveType returns the renderer mappings of the named type. Mappings missing
+// from a definition are taken from the definition of its HTML input type, then
+// from the built in definitions and finally from "text". A type without any
+// definition keeps its name as its HTML input type.
+func (model *Model) resolveType(typeName string) *TypeDefinition {
+	resolved := &TypeDefinition{Name: typeName}
+	seen := map[string]bool{}
+	for name := typeName; name != "" && !seen[name]; {
+		seen[name] = true
+		def, ok := model.GetTypeDefinition(name)
+		if ok {
+			resolved.merge(def)
+		}
+		if builtin, ok := builtinTypes[name]; ok {
+			resolved.merge(builtin)
+			def = builtin
+		}
+		if def == nil {
+			break
+		}
+		name = resolved.HTMLType
+	}
+	// NOTE: types without a definition (e.g. "submit", "hidden") are used as is in HTML
+	if resolved.HTMLType == "" {
+		resolved.HTMLType = typeName
+	}
+	resolved.merge(builtinTypes["text"])
+	return resolved
+}
+
+// validatorFor returns the validator of the named type or nil.
+func (model *Model) validatorFor(typeName string) ValidateFunc {
+	if def, ok := model.GetTypeDefinition(typeName); ok {
+		return def.Validate
+	}
+	return nil
+}
+
+// decoderFor returns the decoder of the named type or nil.
+func (model *Model) decoderFor(typeName string) DecodeFunc {
+	if def, ok := model.GetTypeDefinition(typeName); ok {
+		return def.Decode
+	}
+	return nil
+}
+
+// normalizerFor returns the normalizer of the named type or nil.
+func (model *Model) normalizerFor(typeName string) NormalizeFunc {
+	if def, ok := model.GetTypeDefinition(typeName); ok {
+		return def.Normalize
+	}
+	return nil
+}
+
+// objectValueFor returns the JSON object converter of the named type or nil.
+func (model *Model) objectValueFor(typeName string) ObjectValueFunc {
+	if def, ok := model.GetTypeDefinition(typeName); ok {
+		return def.ObjectValue
+	}
+	return nil
+}
+
+// contextValidatorFor returns the context aware validator of the named type or nil.
+func (model *Model) contextValidatorFor(typeName string) ValidateContextFunc {
+	if def, ok := model.GetTypeDefinition(typeName); ok {
+		return def.ValidateContext
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// typedef_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestTypeDefinitions tests renderers map types using the type definitions.
+func TestTypeDefinitions(t *testing.T) {
+	src := []byte(`id: test_types
+elements:
+  - id: id
+    type: uuid
+    is_primary_id: true
+  - id: author
+    type: orcid
+  - id: doi
+    type: doi
+  - id: rating
+    type: range
+  - id: score
+    type: score
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	// A custom type defined with Define is rendered like its generated element's type
+	model.Define("doi", func() *Element {
+		return &Element{Type: "text"}
+	}, ValidateText)
+	// A custom type can set the mappings explicitly
+	model.DefineType(&TypeDefinition{
+		Name:           "score",
+		Generate:       GenerateNumber,
+		Validate:       ValidateNumber,
+		HTMLType:       "number",
+		SQLTypes:       map[string]string{"sqlite": "int"},
+		TypeScriptType: "number",
+		PythonType:     "int",
+		GoType:         "int64",
+	})
+	if def, ok := model.GetTypeDefinition("doi"); !ok || def.HTMLType != "text" {
+		t.Errorf("expected doi to be defined with HTML type text, got %+v", def)
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for _, test := range []struct {
+		name     string
+		render   RenderFunc
+		expected []string
+	}{
+		{"HTML", ModelToHTML, []string{
+			`type="text" id="author"`,
+			`type="text" id="doi"`,
+			`type="range" id="rating"`,
+			`type="number" id="score"`,
+		}},
+		{"SQL", ModelToSQLiteScheme, []string{"author text", "doi text", "rating num", "score int"}},
+		{"TypeScript", ModelToTypeScriptClass, []string{
+			`author: string = "";`,
+			`doi: string = "";`,
+			`rating: number = 0.0;`,
+			`score: number = 0.0;`,
+		}},
+		{"Python", ModelToPythonClass, []string{
+			"    author: str\n",
+			"    rating: float\n",
+			"    score: int\n",
+			"self.rating = 0.0\n",
+			"self.score = 0\n",
+		}},
+	} {
+		buf.Reset()
+		if err := test.render(buf, model); err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("expected %q in %s\n%s", expected, test.name, buf)
+			}
+		}
+	}
+}
@@ -29,8 +29,6 @@ package models
 import (
 	"encoding/json"
 	"log"
-	"net/mail"
-	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -38,19 +36,21 @@ import (
 
 	// 3rd Party packages
 	"github.com/google/uuid"
-	"github.com/nyaruka/phonenumbers"
 )
 
 const (
-	OrcidPattern = `[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9A-Z]`
-	RORPattern   = `^0[a-hj-km-np-tv-z|0-9]{6}[0-9]{2}$`
-	ISNIPattern  = `[0-9]{4} [0-9]{4} [0-9]{4] [0-9X]{4}|[0-9]{4}-[0-9]{4}-[0-9]{4]-[0-9X]{4}`
+	OrcidPattern = `[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9X]`
+	RORPattern   = `^0[0-9a-hj-km-np-tv-z]{6}[0-9]{2}$`
+	ISNIPattern  = `[0-9]{4} [0-9]{4} [0-9]{4} [0-9]{3}[0-9X]|[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9X]|[0-9]{15}[0-9X]`
 )
 
 var (
 	ReORCID *regexp.Regexp
 	ReROR   *regexp.Regexp
 	ReISNI  *regexp.Regexp
+
+	// rorPrefixes are the URL forms a ROR can be written in, they are matched ignoring case.
+	rorPrefixes = []string{"https://ror.org/", "http://ror.org/", "ror.org/"}
 )
 
 // GenerateROR setups up for an HTML ROR type input element
@@ -72,16 +72,21 @@ func ValidateROR(elem *Element, formValue string) bool {
 	if formValue == "" {
 		return true
 	}
-	if strings.HasPrefix(formValue, "https://ror.org/") {
-		formValue = strings.TrimPrefix(formValue, "https://ror.org/")
-	}
+	formValue = trimIdentifierPrefix(formValue, rorPrefixes...)
 	if !ReROR.MatchString(formValue) {
 		if Debug {
 			log.Printf("DEBUG failed to validate pattern elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
 		}
 		return false
 	}
-	/*FIXME: Need to figure out how to validate (or unencode) the Crockford base 32 value */
+	// The last two digits are a checksum of the Crockford base 32 encoded number.
+	ck, err := rorChecksum(formValue[0:7])
+	if err != nil || ck != formValue[7:] {
+		if Debug {
+			log.Printf("DEBUG failed to validate checksum elem.Id %q, elem.Type %q, value %q, expected %q\n", elem.Id, elem.Type, formValue, ck)
+		}
+		return false
+	}
 	if Debug {
 		log.Printf("DEBUG OK, elem.Id %q, elem.Type %q, value %q\n", elem.Id, elem.Type, formValue)
 	}
@@ -117,6 +122,14 @@ func ValidateUUID(elem *Element, formValue string) bool {
 	return true
 }
 
+// DecodeUUID converts a validated form value into a uuid.UUID
+func DecodeUUID(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	return uuid.Parse(formValue)
+}
+
 // GenerateDate setups up for HTML date input element
 func GenerateDate() *Element {
 	return &Element{
@@ -127,16 +140,16 @@ func GenerateDate() *Element {
 	}
 }
 
-// ValidateDate makes sure the date string conforms to YYYY-MM-DD
+// ValidateDate makes sure the date string conforms to YYYY-MM-DD and
+// is within the element's min, max and step (in days) attributes.
 func ValidateDate(elem *Element, formValue string) bool {
 	if formValue == "" {
 		return true
 	}
-	// FIXME: Need to check against min, max and step values
 	if Debug {
 		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
 	}
-	if _, err := time.Parse("2006-01-02", formValue); err != nil {
+	if err := checkDateBounds(elem, formValue); err != nil {
 		if Debug {
 			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
 		}
@@ -148,6 +161,14 @@ func ValidateDate(elem *Element, formValue string) bool {
 	return true
 }
 
+// DecodeDate converts a YYYY-MM-DD date string into a time.Time
+func DecodeDate(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	return time.Parse("2006-01-02", formValue)
+}
+
 // GenerateDateTimeLocal sets up for HTML input type "datetime-local"
 func GenerateDateTimeLocal() *Element {
 	return &Element{
@@ -160,61 +181,56 @@ func GenerateDateTimeLocal() *Element {
 
 // ValidateDateTimeLocal makes sure the datetime string conforms to
 // Spec: https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-local-date-and-time-string
+// and is within the element's min, max and step (in seconds) attributes.
 func ValidateDateTimeLocal(elem *Element, formValue string) bool {
-	if formValue == "" {
-		return true
-	}
-	// FIXME: Need to check against min, max and step values
-	// See https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-local-date-and-time-string for validation steps
 	if formValue == "" {
 		return true
 	}
 	if Debug {
 		log.Printf("DEBUG validating elem.Id %q, elem.Type %q, value %q \n", elem.Id, elem.Type, formValue)
 	}
-	// If we have timezone info so handle as RFC3339 validation
-	if len(formValue) >= 20 {
-		if _, err := time.Parse(time.RFC3339, formValue); err != nil {
-			if Debug {
-				log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
-			}
-			return false
-		}
-		if Debug {
-			log.Printf("DEBUG OK, elem.Id %q, elem.Type %q, value %q\n", elem.Id, elem.Type, formValue)
-		}
-		return true
-	}
-	// Parse date component first
-	if _, err := time.Parse("2006-01-02", formValue[0:10]); err != nil {
+	if err := checkDateTimeLocalBounds(elem, formValue); err != nil {
 		if Debug {
 			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
 		}
 		return false
 	}
-	// String doesn't include the time so fail
-	if len(formValue) <= 10 {
-		if Debug {
-			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, "formValue length less than 10")
-		}
-		return false
+	if Debug {
+		log.Printf("DEBUG OK, elem.Id %q, elem.Type %q, value %q\n", elem.Id, elem.Type, formValue)
 	}
-	if formValue[10:11] != "T" && formValue[10:11] != " " {
-		if Debug {
-			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, "missing T or space between date and time")
-		}
-		return false
+	return true
+}
+
+// parseDateTimeLocal parses a local date and time string. If the value includes
+// timezone information it is parsed as RFC3339.
+func parseDateTimeLocal(formValue string) (time.Time, error) {
+	layouts := []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
 	}
-	if _, err := time.Parse("15:04:05", formValue[11:19]); err != nil {
-		if Debug {
-			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+	if t, err := time.Parse(time.RFC3339, formValue); err == nil {
+		return t, nil
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range layouts {
+		if t, err = time.ParseInLocation(layout, formValue, time.Local); err == nil {
+			return t, nil
 		}
-		return false
 	}
-	if Debug {
-		log.Printf("DEBUG OK, elem.Id %q, elem.Type %q, value %q\n", elem.Id, elem.Type, formValue)
+	return t, err
+}
+
+// DecodeDateTimeLocal converts a local date and time string into a time.Time
+func DecodeDateTimeLocal(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
 	}
-	return true
+	return parseDateTimeLocal(formValue)
 }
 
 // GenerateMonth sets up for HTML input type "month"
@@ -227,15 +243,29 @@ func GenerateMonth() *Element {
 	}
 }
 
-// ValidateMonth parses the string for a year and month value, i.e. YYYY-MM style date string
+// ValidateMonth parses the string for a year and month value, i.e. YYYY-MM style date string,
+// and checks it is within the element's min, max and step (in months) attributes.
 func ValidateMonth(elem *Element, formValue string) bool {
-	// FIXME: Need to check against min, max and step values
-	if _, err := time.Parse("2006-02", formValue); err != nil {
+	if formValue == "" {
+		return true
+	}
+	if err := checkMonthBounds(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
 }
 
+// DecodeMonth converts a YYYY-MM string into a time.Time for the first day of the month
+func DecodeMonth(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	return time.Parse("2006-01", formValue)
+}
+
 // GenerateColor sets up for HTML input type "color"
 func GenerateColor() *Element {
 	return &Element{
@@ -268,9 +298,14 @@ func GenerateEmail() *Element {
 	}
 }
 
-// ValidateEmail parses email address to confirm it is valid
+// ValidateEmail parses email address to confirm it is valid. If the element's "strict"
+// attribute is true only a bare address with a valid domain name is accepted, e.g.
+// "Jane <jane@example.org>" is rejected.
 func ValidateEmail(elem *Element, formValue string) bool {
-	if _, err := mail.ParseAddress(formValue); err != nil {
+	if _, err := parseEmailProfile(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
@@ -319,14 +354,33 @@ func GenerateNumber() *Element {
 	}
 }
 
-// ValidateNumber implements a number validation using the json package.
+// ValidateNumber implements a number validation using the json package. If the element
+// has min, max or step attributes the number is checked against them.
 func ValidateNumber(elem *Element, formValue string) bool {
-	if _, err := jsonDecodeNumber(formValue); err != nil {
+	if formValue == "" {
+		return true
+	}
+	if err := checkNumberBounds(elem, formValue, false); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
 }
 
+// DecodeNumber converts a number into an int64 if it is a whole number,
+// otherwise it is converted to a float64.
+func DecodeNumber(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(formValue, 10, 64); err == nil {
+		return i, nil
+	}
+	return strconv.ParseFloat(formValue, 64)
+}
+
 // GenerateRange sets up for an HTML input "range" (defauting is min 0 to max 100, step 1)
 func GenerateRange() *Element {
 	return &Element{
@@ -340,42 +394,17 @@ func GenerateRange() *Element {
 	}
 }
 
-// ValidateRange retrieves the form's value as a float64 then checks if it is in range.  Min and max must befined in
-// the attributes of the element since they are required to make the comparison. NOTE: ValidateRange isn't currently checking
-// the step value as I don't know if the value of the input element is supposed to be an integer for real number.
+// ValidateRange retrieves the form's value as a float64 then checks if it is in range and
+// matches the step. Like the browser min defaults to 0 and max defaults to 100 when
+// they are not defined in the element's attributes.
 func ValidateRange(elem *Element, formValue string) bool {
-	var (
-		minNumber   float64
-		maxNumber   float64
-		numberValue float64
-		err         error
-	)
-	// First make sure elem has a minimum and maximum defined in it's attributes
-	if val, ok := elem.Attributes["min"]; ok {
-		minNumber, err = jsonDecodeNumber(val)
-		if err != nil {
-			return false
-		}
-	} else {
-		return false
-	}
-	if val, ok := elem.Attributes["max"]; ok {
-		maxNumber, err = jsonDecodeNumber(val)
-		if err != nil {
-			return false
+	if err := checkNumberBounds(elem, formValue, true); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
 		}
-	} else {
-		return false
-	}
-
-	numberValue, err = jsonDecodeNumber(formValue)
-	if err != nil {
 		return false
 	}
-	if numberValue >= minNumber && numberValue <= maxNumber {
-		return true
-	}
-	return false
+	return true
 }
 
 // GenerateTel sets up for an HTML input type "tel" (i.e. telephone number).
@@ -389,10 +418,14 @@ func GenerateTel() *Element {
 	}
 }
 
-// ValidateTel validates formValue conforms to a phone number.
+// ValidateTel validates formValue conforms to a phone number. Numbers without a country
+// code are taken to be in the element's "region" (DefaultTelRegion if not set). If the
+// "number_type" attribute is set the number must be a valid mobile or fixed_line number.
 func ValidateTel(elem *Element, formValue string) bool {
-	// NOTE: I am defaulting to US numbers because Caltech Library is in the US
-	if _, err := phonenumbers.Parse(formValue, "US"); err != nil {
+	if _, err := parseTelProfile(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
@@ -409,15 +442,30 @@ func GenerateTime() *Element {
 	}
 }
 
-// ValidateTime validates the formValue is a time format
+// ValidateTime validates the formValue is a time format (HH:MM, HH:MM:SS or HH:MM:SS.sss)
+// and is within the element's min, max and step (in seconds) attributes. Like the
+// browser if max is before min the range wraps past midnight.
 func ValidateTime(elem *Element, formValue string) bool {
-	//FIXME: Need to check against min and max values
-	if _, err := time.Parse("15:04:05", formValue); err != nil {
+	if formValue == "" {
+		return true
+	}
+	if err := checkTimeBounds(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
 }
 
+// DecodeTime converts a time string into a time.Time (the date is January 1, year zero).
+func DecodeTime(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	return parseTime(formValue)
+}
+
 // GenerateURL sets up for an HTML input type "url"
 func GenerateURL() *Element {
 	return &Element{
@@ -429,9 +477,16 @@ func GenerateURL() *Element {
 	}
 }
 
-// ValidateURL validates a formValue is a URL
+// ValidateURL validates a formValue is an absolute URL. The element's "schemes",
+// "require_host" and "idn" attributes can restrict the URLs accepted.
 func ValidateURL(elem *Element, formValue string) bool {
-	if _, err := url.Parse(formValue); err != nil {
+	if formValue == "" {
+		return true
+	}
+	if _, err := parseURLProfile(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
+		}
 		return false
 	}
 	return true
@@ -442,32 +497,34 @@ func GenerateWeek() *Element {
 	return &Element{
 		Type: "week",
 		Attributes: map[string]string{
-			"placeholder": "Input as YYYY-WW where WW is week nuber, e.g. 2024-51",
-			"pattern":     "[0-9]{4}-[0-5][0-9]",
+			"placeholder": "Input as YYYY-Www where ww is the ISO 8601 week number, e.g. 2024-W51",
+			"pattern":     "[0-9]{4}-W[0-5][0-9]",
 		},
 	}
 }
 
-// ValidateWeek attempts to validate a week number with year, string is WW-YYYY formatted
-// NOTE: this is a crude validation since some years have 52 weeks other 53 depending on how
-// the days of the week line up against the year.
-//
-// Also noted is this input element isn't widely support by browser so I might drop in the future.
+// ValidateWeek validates an ISO 8601 week string, YYYY-Www, e.g. 2024-W51. The week number
+// is checked against the number of weeks in the year (52 or 53) as well as the element's
+// min, max and step (in weeks) attributes.
 func ValidateWeek(elem *Element, formValue string) bool {
-	if strings.Index(formValue, "-") == 2 {
-		weekNum, err := strconv.Atoi(formValue[0:2])
-		if err != nil {
-			return false
-		}
-		_, err = strconv.Atoi(formValue[3:])
-		if err != nil {
-			return false
-		}
-		if weekNum > 0 && weekNum <= 53 {
-			return true
+	if formValue == "" {
+		return true
+	}
+	if err := checkWeekBounds(elem, formValue); err != nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, err)
 		}
+		return false
 	}
-	return false
+	return true
+}
+
+// DecodeWeek converts an ISO 8601 week string into a time.Time for the Monday starting the week.
+func DecodeWeek(elem *Element, formValue string) (interface{}, error) {
+	if formValue == "" {
+		return nil, nil
+	}
+	return parseWeek(formValue)
 }
 
 // GenerateCheckbox sets up for an HTML input type "checkbox"
@@ -479,11 +536,26 @@ func GenerateCheckbox() *Element {
 }
 
 // ValidateCheckbox checks is the form value was provided, returns false if empty string recieved for value.
+// If the element has options (a checkbox group) the value must be one of the option values.
 func ValidateCheckbox(elem *Element, formValue string) bool {
+	// A checkbox group's values must be among its options.
+	if len(elem.Options) > 0 {
+		return elem.HasOption(formValue)
+	}
 	// Checkbox return their string value if checked.
 	return strings.TrimSpace(formValue) != ""
 }
 
+// DecodeCheckbox converts a checkbox value into a bool. An empty value, "false",
+// "off", "no" and "0" decode as false, any other value means the box was checked.
+func DecodeCheckbox(elem *Element, formValue string) (interface{}, error) {
+	switch strings.ToLower(strings.TrimSpace(formValue)) {
+	case "", "false", "off", "no", "0":
+		return false, nil
+	}
+	return true, nil
+}
+
 // GenerateImage sets up for an HTML input type "image"
 func GenerateImage() *Element {
 	return &Element{
@@ -524,11 +596,41 @@ func GenerateRadio() *Element {
 	}
 }
 
+// ValidateRadio checks a radio value was provided. If the element has options the
+// value must be one of the option values.
 func ValidateRadio(elem *Element, formValue string) bool {
+	if len(elem.Options) > 0 {
+		return elem.HasOption(formValue)
+	}
 	// Checkbox return their string value if checked.
 	return strings.TrimSpace(formValue) != ""
 }
 
+// GenerateSelect sets up for an HTML select element. The choices are held in the
+// element's options. Set the "multiple" attribute to allow more than one choice.
+func GenerateSelect() *Element {
+	return &Element{
+		Type:       "select",
+		Attributes: map[string]string{},
+		Options:    []map[string]string{},
+	}
+}
+
+// ValidateSelect checks the value is one of the element's option values. An empty
+// value means no choice was made.
+func ValidateSelect(elem *Element, formValue string) bool {
+	if formValue == "" {
+		return true
+	}
+	if !elem.HasOption(formValue) {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q not in options %+v\n", elem.Id, elem.Type, formValue, elem.OptionValues())
+		}
+		return false
+	}
+	return true
+}
+
 // GenerateButton sets up for an HTML input type "button"
 func GenerateButton() *Element {
 	return &Element{
@@ -618,30 +720,17 @@ func ValidateISNI(elem *Element, formValue string) bool {
 		}
 		return false
 	}
-	r := 0
-	ck := 0
-	for pos := 0; pos < 15; pos++ {
-		x, err := strconv.Atoi(formValue[pos : pos+1])
-		if err != nil {
-			if Debug {
-				log.Printf("DEBUG validating isni elem.Id %q, elem.Type %q, value %q: %s\n", elem.Id, elem.Type, formValue, err)
-			}
-			return false
-		}
-		r = (r + x) * 2
-	}
-	lastDigit, err := strconv.Atoi(formValue[len(formValue)-1:])
+	ck, err := mod11_2CheckDigit(formValue[0:15])
 	if err != nil {
 		if Debug {
 			log.Printf("DEBUG validating isni elem.Id %q, elem.Type %q, value %q: %s\n", elem.Id, elem.Type, formValue, err)
 		}
 		return false
 	}
-	ck = (12 - r%11) % 11
 	if Debug {
-		log.Printf("DEBUG validating isni elem.Id %q, elem.Type %q, value %q\n, result: %t", elem.Id, elem.Type, formValue, (ck == lastDigit))
+		log.Printf("DEBUG validating isni elem.Id %q, elem.Type %q, value %q\n, result: %t", elem.Id, elem.Type, formValue, (ck == formValue[15:]))
 	}
-	return ck == lastDigit
+	return ck == formValue[15:]
 }
 
 // GenerateORCID sets up for an HTML input type text using a pattern for ORCID
@@ -662,10 +751,14 @@ func ValidateORCID(elem *Element, formValue string) bool {
 		return true
 	}
 	/* Based on https://idutils.readthedocs.io/en/latest/_modules/idutils.html#is_orcid */
-	if strings.HasPrefix(formValue, "https://orcid.org/") {
-		formValue = strings.TrimPrefix(formValue, "https://orcid.org/")
+	for _, prefix := range []string{"https://orcid.org/", "http://orcid.org/", "orcid.org/"} {
+		formValue = strings.TrimPrefix(formValue, prefix)
 	}
 	formValue = strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(formValue, "-", ""), " ", ""))
+	if formValue == "" {
+		// NOTE: a bare prefix or spaces isn't an ORCID
+		return false
+	}
 	if ValidateISNI(elem, formValue) {
 		// Remove tailing check digit, then convert to integer
 		//log.Printf("DEBUG formValue: %q -> formValue[0:len(formValue) -1]: %q", formValue, formValue[0:len(formValue)-1])
@@ -676,10 +769,13 @@ func ValidateORCID(elem *Element, formValue string) bool {
 			}
 			return false
 		}
+		// ORCID assigns ids from 0000-0001-5000-0007 to 0000-0003-5000-0001 and
+		// from 0009-0000-0000-0000 to 0009-0010-0000-0000.
+		inRange := (val >= 15000000 && val <= 35000000) || (val >= 900000000000 && val <= 900100000000)
 		if Debug {
-			log.Printf("DEBUG testing orcid ranges: %t, elem.Id %q, elem.Type %q, value %q", (val >= 15000000) && (val <= 35000000), elem.Id, elem.Type, formValue)
+			log.Printf("DEBUG testing orcid ranges: %t, elem.Id %q, elem.Type %q, value %q", inRange, elem.Id, elem.Type, formValue)
 		}
-		return (val >= 15000000) && (val <= 35000000)
+		return inRange
 	}
 	if Debug {
 		log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q: %s \n", elem.Id, elem.Type, formValue, "does not confirm to isni")
@@ -687,26 +783,10 @@ func ValidateORCID(elem *Element, formValue string) bool {
 	return false
 }
 
+// SetDefaultTypes makes the model inherit its types from the DefaultRegistry. Models
+// do this without being told so calling it is only needed to undo SetTypeRegistry.
 func SetDefaultTypes(model *Model) {
-	model.Define("date", GenerateDate, ValidateDate)
-	model.Define("datetime-local", GenerateDateTimeLocal, ValidateDateTimeLocal)
-	model.Define("month", GenerateMonth, ValidateMonth)
-	model.Define("color", GenerateColor, ValidateColor)
-	model.Define("email", GenerateEmail, ValidateEmail)
-	model.Define("text", GenerateText, ValidateText)
-	model.Define("number", GenerateNumber, ValidateNumber)
-	model.Define("range", GenerateRange, ValidateRange)
-	model.Define("tel", GenerateTel, ValidateTel)
-	model.Define("time", GenerateTime, ValidateTime)
-	model.Define("url", GenerateURL, ValidateURL)
-	model.Define("checkbox", GenerateCheckbox, ValidateCheckbox)
-	model.Define("password", GeneratePassword, ValidatePassword)
-	model.Define("radio", GenerateRadio, ValidateRadio)
-	model.Define("textarea", GenerateTextarea, ValidateTextarea)
-	model.Define("orcid", GenerateORCID, ValidateORCID)
-	model.Define("isni", GenerateISNI, ValidateISNI)
-	model.Define("uuid", GenerateUUID, ValidateUUID)
-	model.Define("ror", GenerateROR, ValidateROR)
+	model.SetTypeRegistry(DefaultRegistry)
 
 	// NOTE: The following are not in the default but their usefulness
 	// in the context of persisting data is not clear.
@@ -715,7 +795,6 @@ func SetDefaultTypes(model *Model) {
 	//model.Define("reset", GenerateReset, ValidateReset)
 	//model.Define("submit", GenerateSubmit, ValidateSubmit)
 	//model.Define("button", GenerateButton, ValidateButton)
-	//model.Define("week", GenerateWeek, ValidateWeek)
 	//model.Define("image", GenerateImage, ValidateImage)
 }
 
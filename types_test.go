@@ -74,9 +74,40 @@ func TestORCIDRegExp(t *testing.T) {
 	if ValidateORCID(elem, orcid) {
 		t.Errorf("expected orcid to validate false, got true")
 	}
+	// Josiah Carberry's ORCID (0000-0002-1825-0097) and 0000-0002-1694-233X, the example
+	// ending in an "X" check digit from ORCID's "Structure of the ORCID Identifier" article
+	for _, orcid := range []string{`0000-0002-1825-0097`, `0000-0002-1694-233X`, `0000-0002-1694-233x`, `https://orcid.org/0000-0002-1825-0097`, `0000000218250097`} {
+		if !ValidateORCID(elem, orcid) {
+			t.Errorf("expected orcid %q to validate true, got false", orcid)
+		}
+	}
+	// Bad check digits, an ISNI outside the ORCID ranges and values empty once the prefix and spaces are removed
+	for _, orcid := range []string{`0000-0002-1825-0098`, `0000-0002-1694-2330`, `0000-0001-2146-438X`, ` `, `https://orcid.org/`, `-`} {
+		if ValidateORCID(elem, orcid) {
+			t.Errorf("expected orcid %q to validate false, got true", orcid)
+		}
+	}
 	//SetDebug(false)
 }
 
+// TestISNI tests the ISNI MOD 11-2 check digit validation.
+func TestISNI(t *testing.T) {
+	elem := new(Element)
+	elem.Id = "isni"
+	elem.Type = "isni"
+	// Caltech's ISNI ends with the check character "X"
+	for _, isni := range []string{`0000 0001 2146 438X`, `0000-0001-2146-438X`, `000000012146438x`, `0000 0001 2103 2683`} {
+		if !ValidateISNI(elem, isni) {
+			t.Errorf("expected isni %q to validate true, got false", isni)
+		}
+	}
+	for _, isni := range []string{`0000 0001 2146 4380`, `0000 0001 2103 2684`, `0000 0001 2146 43`, `0000 0001 2146 43AX`} {
+		if ValidateISNI(elem, isni) {
+			t.Errorf("expected isni %q to validate false, got true", isni)
+		}
+	}
+}
+
 // TestDatetimeLocal tests the "datetime-local" structure
 func TestDatetimeLocal(t *testing.T) {
 	// Debug = true
@@ -135,6 +166,27 @@ func TestROR(t *testing.T) {
 	if !ValidateROR(elem, val) {
 		t.Errorf("expected ValidateROR(elem, %q) to return true, return false", val)
 	}
+	// Caltech, California Digital Library and Crossref
+	for _, val := range []string{`05dxps055`, `03yrm5c26`, `02twcfp32`} {
+		if !ValidateROR(elem, val) {
+			t.Errorf("expected ValidateROR(elem, %q) to return true, return false", val)
+		}
+	}
+	// Every form NormalizeROR accepts also validates
+	for _, val := range []string{`https://ror.org/05dxps055`, `http://ror.org/05dxps055`, `ror.org/05dxps055`} {
+		if !ValidateROR(elem, val) {
+			t.Errorf("expected ValidateROR(elem, %q) to return true, return false", val)
+		}
+		if got := NormalizeROR(elem, val); got != `05dxps055` {
+			t.Errorf("expected NormalizeROR(elem, %q) to return 05dxps055, got %q", val, got)
+		}
+	}
+	// Bad checksums and characters outside the Crockford alphabet
+	for _, val := range []string{`05dxps056`, `03yrm5c62`, `05dxpu055`, `15dxps055`} {
+		if ValidateROR(elem, val) {
+			t.Errorf("expected ValidateROR(elem, %q) to return false, return true", val)
+		}
+	}
 	//SetDebug(false);
 }
 
@@ -316,7 +368,7 @@ elements:
 		t.Error("Model failed to validate")
 		for _, elem := range model.Elements {
 			if val, ok := formData[elem.Id]; ok {
-				if validator, ok := model.validators[elem.Type]; ok {
+				if validator := model.validatorFor(elem.Type); validator != nil {
 					if !validator(elem, val.(string)) {
 						t.Errorf("elem.Id %q, elem.Type %q failed to validate value %q", elem.Id, elem.Type, val)
 					}
@@ -328,3 +380,88 @@ elements:
 	}
 	SetDebug(false)
 }
+
+// TestMinMaxStep tests min, max and step are enforced for the temporal and numeric types.
+func TestMinMaxStep(t *testing.T) {
+	testData := []struct {
+		Type     string
+		Attr     map[string]string
+		Value    string
+		Expected bool
+	}{
+		{"date", map[string]string{"min": "2024-01-01", "max": "2024-12-31"}, "2024-06-15", true},
+		{"date", map[string]string{"min": "2024-01-01", "max": "2024-12-31"}, "2023-12-31", false},
+		{"date", map[string]string{"min": "2024-01-01", "max": "2024-12-31"}, "2025-01-01", false},
+		{"date", map[string]string{"min": "2024-01-01", "step": "7"}, "2024-01-15", true},
+		{"date", map[string]string{"min": "2024-01-01", "step": "7"}, "2024-01-16", false},
+		{"datetime-local", map[string]string{"min": "2024-01-01T09:00", "max": "2024-01-01T17:00"}, "2024-01-01T12:30", true},
+		{"datetime-local", map[string]string{"min": "2024-01-01T09:00", "max": "2024-01-01T17:00"}, "2024-01-01T17:00:01", false},
+		{"datetime-local", map[string]string{"step": "900"}, "2024-01-01T12:45", true},
+		{"datetime-local", map[string]string{"step": "900"}, "2024-01-01T12:50", false},
+		{"datetime-local", map[string]string{}, "2024-01-01T12", false},
+		{"month", map[string]string{"min": "2024-01", "max": "2024-06"}, "2024-03", true},
+		{"month", map[string]string{"min": "2024-01", "max": "2024-06"}, "2024-07", false},
+		{"month", map[string]string{"min": "2024-01", "step": "3"}, "2024-04", true},
+		{"month", map[string]string{"min": "2024-01", "step": "3"}, "2024-05", false},
+		{"month", map[string]string{}, "2024-13", false},
+		{"time", map[string]string{"min": "09:00", "max": "17:00"}, "12:30", true},
+		{"time", map[string]string{"min": "09:00", "max": "17:00"}, "08:59:59", false},
+		{"time", map[string]string{"min": "22:00", "max": "02:00"}, "23:30", true},
+		{"time", map[string]string{"min": "22:00", "max": "02:00"}, "01:00", true},
+		{"time", map[string]string{"min": "22:00", "max": "02:00"}, "12:00", false},
+		{"time", map[string]string{"step": "1800"}, "10:30", true},
+		{"time", map[string]string{"step": "1800"}, "10:15", false},
+		{"number", map[string]string{"min": "1", "max": "10"}, "5.5", true},
+		{"number", map[string]string{"min": "1", "max": "10"}, "11", false},
+		{"number", map[string]string{"min": "1", "step": "2"}, "7", true},
+		{"number", map[string]string{"min": "1", "step": "2"}, "8", false},
+		{"number", map[string]string{"step": "0.01"}, "12.95", true},
+		{"number", map[string]string{"step": "0.01"}, "12.955", false},
+		{"number", map[string]string{"step": "any"}, "12.955", true},
+		{"range", map[string]string{}, "50", true},
+		{"range", map[string]string{}, "101", false},
+		{"range", map[string]string{"min": "0", "max": "1", "step": "0.25"}, "0.75", true},
+		{"range", map[string]string{"min": "0", "max": "1", "step": "0.25"}, "0.8", false},
+		{"week", map[string]string{}, "2020-W53", true},
+		{"week", map[string]string{}, "2021-W53", false},
+		{"week", map[string]string{}, "2024-W00", false},
+		{"week", map[string]string{}, "2024-51", false},
+		{"week", map[string]string{"min": "2024-W10", "max": "2024-W20"}, "2024-W15", true},
+		{"week", map[string]string{"min": "2024-W10", "max": "2024-W20"}, "2025-W01", false},
+		{"week", map[string]string{"min": "2024-W10", "step": "2"}, "2024-W14", true},
+		{"week", map[string]string{"min": "2024-W10", "step": "2"}, "2024-W15", false},
+	}
+	model := new(Model)
+	SetDefaultTypes(model)
+	for _, td := range testData {
+		elem := &Element{Id: "test", Type: td.Type, Attributes: td.Attr}
+		validator := model.validatorFor(td.Type)
+		if validator == nil {
+			t.Errorf("missing validator for %q", td.Type)
+			continue
+		}
+		if got := validator(elem, td.Value); got != td.Expected {
+			t.Errorf("expected %t, got %t for %s %q with %+v", td.Expected, got, td.Type, td.Value, td.Attr)
+		}
+	}
+	// Make sure the failure codes are reported
+	elem := &Element{Id: "test", Type: "date", Attributes: map[string]string{"min": "2024-01-01", "step": "7"}}
+	if code, _ := describeFailure(elem, "2023-01-01"); code != CodeOutOfRange {
+		t.Errorf("expected %q, got %q", CodeOutOfRange, code)
+	}
+	if code, _ := describeFailure(elem, "2024-01-02"); code != CodeStepMismatch {
+		t.Errorf("expected %q, got %q", CodeStepMismatch, code)
+	}
+	// The pattern is anchored and uses the same syntax as the constraint layer
+	elem = &Element{Id: "test", Type: "text", Pattern: `\d{3}`}
+	for _, val := range []string{"1234", "12a"} {
+		if code, _ := describeFailure(elem, val); code != CodePatternMismatch {
+			t.Errorf("expected %q for %q, got %q", CodePatternMismatch, val, code)
+		}
+	}
+	// A value that can't be parsed gets a stable message
+	elem = &Element{Id: "test", Type: "number", Attributes: map[string]string{"min": "0"}}
+	if code, msg := describeFailure(elem, "a"); code != CodeInvalid || msg != "not a valid number value" {
+		t.Errorf("expected %q, \"not a valid number value\", got %q, %q", CodeInvalid, code, msg)
+	}
+}
@@ -49,6 +49,7 @@ Model: %s
 */
 
 `, model.Id, model.Description)
+	writeRuleComments(out, "//", model)
 
 	className := model.Id
 	if len(className) > 1 {
@@ -62,59 +63,55 @@ export interface %s {
 `, interfaceName, className, interfaceName)
 	for _, elem := range model.Elements {
 		varName := elem.Id
-		varType := mapTypeToTypeScript(elem)
+		varType := mapTypeToTypeScript(model, elem)
 		fmt.Fprintf(out, "\t%s: %s;\n", varName, varType)
 	}
-	fmt.Fprintln(out, `}
-
-`)
+	fmt.Fprint(out, "}\n\n\n")
 
 	fmt.Fprintf(out, `// %s's class definition
 export class %s implements %s {
 `, className, className, interfaceName)
 	for _, elem := range model.Elements {
 		varName := elem.Id
-		varType := mapTypeToTypeScript(elem)
-		switch varType {
-		case "string":
+		varType := mapTypeToTypeScript(model, elem)
+		switch {
+		case varType == "string":
 			varType = "string = \"\""
-		case "number":
+		case varType == "number":
 			varType = "number = 0.0"
-		case "boolean":
+		case varType == "boolean":
 			varType = "boolean = false"
+		case strings.HasSuffix(varType, "[]"):
+			varType = varType + " = []"
+		case strings.HasPrefix(varType, "{"):
+			// An object type (e.g. a geo_point) defaults to zeros
+			varType = fmt.Sprintf("%s = %s", varType, strings.ReplaceAll(varType, ":number", ": 0"))
+		case strings.HasPrefix(varType, "\""):
+			// A string literal union defaults to the first option
+			varType = fmt.Sprintf("%s = %s", varType, strings.SplitN(varType, " | ", 2)[0])
 		}
 		fmt.Fprintf(out, "\t%s: %s;\n", varName, varType)
 	}
-	fmt.Fprintln(out, `}
-
-`)
+	fmt.Fprint(out, "}\n\n\n")
 	return nil
 }
 
-func mapTypeToTypeScript(elem *Element) string {
-	dTypes := map[string]string{
-		"date":           "string",
-		"datetime-local": "string",
-		"month":          "string",
-		"color":          "string",
-		"email":          "string",
-		"number":         "number",
-		"range":          "number[]",
-		"text":           "string",
-		"tel":            "string",
-		"time":           "string",
-		"url":            "string",
-		"checkbox":       "boolean",
-		"password":       "string",
-		"radio":          "string",
-		"textarea":       "string",
-		"orcid":          "string",
-		"isni":           "string",
-		"uuid":           "string",
-		"ror":            "string",
+// mapTypeToTypeScript returns the TypeScript type of an element using the model's type definitions.
+func mapTypeToTypeScript(model *Model, elem *Element) string {
+	eType := strings.ToLower(elem.Type)
+	if (eType == "select" || eType == "radio" || eType == "checkbox") && len(elem.Options) > 0 {
+		literals := []string{}
+		for _, val := range elem.OptionValues() {
+			literals = append(literals, fmt.Sprintf("%q", val))
+		}
+		if elem.IsMultiple() {
+			return fmt.Sprintf("(%s)[]", strings.Join(literals, " | "))
+		}
+		return strings.Join(literals, " | ")
 	}
-	if val, ok := dTypes[elem.Type]; ok {
-		return val
+	varType := model.resolveType(elem.Type).TypeScriptType
+	if elem.IsMultiple() && !strings.HasSuffix(varType, "[]") {
+		return varType + "[]"
 	}
-	return "string"
+	return varType
 }
@@ -55,3 +55,13 @@ func getAttributeIds(m map[string]string) []string {
 	}
 	return ids
 }
+
+// inList checks if a string is in a list of strings
+func inList(l []string, s string) bool {
+	for _, val := range l {
+		if val == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,553 @@
+// validation.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validation failure codes. These are stable values that can be returned to
+// a submitter (e.g. in a JSON response from a web service) and matched on by
+// client code.
+const (
+	// CodeMissing indicates an expected element value was not submitted.
+	CodeMissing = "missing"
+	// CodeUnexpectedField indicates a value was submitted for an element not found in the model.
+	CodeUnexpectedField = "unexpected_field"
+	// CodePatternMismatch indicates the value did not match the element's pattern.
+	CodePatternMismatch = "pattern_mismatch"
+	// CodeTooShort indicates the value is shorter than the element's minlength.
+	CodeTooShort = "too_short"
+	// CodeTooLong indicates the value is longer than the element's maxlength.
+	CodeTooLong = "too_long"
+	// CodeTooFewValues indicates a multiple element has fewer values than min_items.
+	CodeTooFewValues = "too_few_values"
+	// CodeTooManyValues indicates more values were submitted than the element allows.
+	CodeTooManyValues = "too_many_values"
+	// CodeOutOfRange indicates the value is outside the element's min and max.
+	CodeOutOfRange = "out_of_range"
+	// CodeStepMismatch indicates the value doesn't match the element's step.
+	CodeStepMismatch = "step_mismatch"
+	// CodeRuleFailed indicates the data doesn't satisfy one of the model's rules.
+	CodeRuleFailed = "rule_failed"
+	// CodeInvalidRule indicates one of the model's rules can't be evaluated.
+	CodeInvalidRule = "invalid_rule"
+	// CodeNotUnique indicates the value of a unique element is held by another record.
+	CodeNotUnique = "not_unique"
+	// CodeNotFound indicates the record referenced by the value doesn't exist.
+	CodeNotFound = "not_found"
+	// CodeNoValidator indicates the element's type has no validator defined.
+	CodeNoValidator = "no_validator"
+	// CodeInvalid indicates the element's validator rejected the value.
+	CodeInvalid = "invalid"
+	// CodeTypeMismatch indicates a Go struct field can't hold the element's value.
+	CodeTypeMismatch = "type_mismatch"
+	// CodeUnboundElement indicates an element has no matching Go struct field.
+	CodeUnboundElement = "unbound_element"
+)
+
+// ValidationMode controls which of a model's elements must be present
+// when validating data.
+type ValidationMode int
+
+const (
+	// CreateMode requires every required element to be present. An element
+	// is required if it has a "required" attribute or is the object identifier.
+	CreateMode ValidationMode = iota
+
+	// PartialMode only validates the elements present in the data, e.g. when
+	// handling an HTTP PATCH request.
+	PartialMode
+
+	// IgnoreGeneratedMode works like CreateMode except elements with a
+	// generator (e.g. autoincrement, current_timestamp) are not required.
+	IgnoreGeneratedMode
+)
+
+// String returns the name of the validation mode.
+func (mode ValidationMode) String() string {
+	switch mode {
+	case CreateMode:
+		return "create"
+	case PartialMode:
+		return "partial"
+	case IgnoreGeneratedMode:
+		return "ignore_generated"
+	}
+	return fmt.Sprintf("ValidationMode(%d)", int(mode))
+}
+
+// ValidationError describes a single validation failure for an element.
+type ValidationError struct {
+	// ElementId is the id of the element that failed validation
+	ElementId string `json:"element_id,omitempty" yaml:"element_id,omitempty"`
+
+	// ElementType is the type of the element that failed validation
+	ElementType string `json:"element_type,omitempty" yaml:"element_type,omitempty"`
+
+	// Value holds the rejected value (as received in the form data)
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Code is a stable machine readable code, e.g. "missing", "pattern_mismatch"
+	Code string `json:"code" yaml:"code"`
+
+	// Message is a human readable description of the failure
+	Message string `json:"message" yaml:"message"`
+
+	// Path is a JSON pointer (RFC 6901) to the rejected value, e.g. "/creators/0/family"
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Rule is the id of the model rule that failed
+	Rule string `json:"rule,omitempty" yaml:"rule,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	if e.ElementId == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.ElementId, e.Message)
+}
+
+// jsonPointer appends a reference token to a JSON pointer escaping it as
+// described in RFC 6901.
+func jsonPointer(path string, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return path + "/" + token
+}
+
+// ValidationResult holds the validation failures found when validating
+// data against a model. An empty result means the data validated.
+type ValidationResult struct {
+	Errors []*ValidationError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// OK returns true if no validation failures were recorded.
+func (r *ValidationResult) OK() bool {
+	return r == nil || len(r.Errors) == 0
+}
+
+// Add records a validation failure for an element.
+func (r *ValidationResult) Add(elem *Element, value string, code string, msg string) {
+	path := ""
+	if elem != nil && elem.Id != "" {
+		path = jsonPointer("", elem.Id)
+	}
+	r.AddPath(path, elem, value, code, msg)
+}
+
+// AddPath records a validation failure for an element's value found at the
+// JSON pointer path.
+func (r *ValidationResult) AddPath(path string, elem *Element, value string, code string, msg string) {
+	vErr := &ValidationError{
+		Value:   value,
+		Code:    code,
+		Message: msg,
+		Path:    path,
+	}
+	if elem != nil {
+		vErr.ElementId = elem.Id
+		vErr.ElementType = elem.Type
+	}
+	r.Errors = append(r.Errors, vErr)
+}
+
+// Error implements the error interface, it returns the failures as a single string.
+func (r *ValidationResult) Error() string {
+	msgs := []string{}
+	for _, e := range r.Errors {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual validation failures so they can be
+// inspected with errors.Is and errors.As.
+func (r *ValidationResult) Unwrap() []error {
+	errs := []error{}
+	for _, e := range r.Errors {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// Err returns the result as an error or nil if validation succeeded.
+func (r *ValidationResult) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return r
+}
+
+// ValidateReport validates form data expressed as map[string]string returning
+// a ValidationResult holding one entry per failure. All required elements
+// must be present (i.e. CreateMode).
+func (model *Model) ValidateReport(formData map[string]string) *ValidationResult {
+	return model.ValidateMode(formData, CreateMode)
+}
+
+// ValidateMode validates form data expressed as map[string]string using
+// the validation mode provided.
+func (model *Model) ValidateMode(formData map[string]string, mode ValidationMode) *ValidationResult {
+	data := map[string]interface{}{}
+	for k, v := range formData {
+		data[k] = v
+	}
+	return model.validateData(data, mode)
+}
+
+// ValidateMapInterfaceReport normalizes the map interface values before validating
+// them returning a ValidationResult holding one entry per failure. All required
+// elements must be present (i.e. CreateMode).
+func (model *Model) ValidateMapInterfaceReport(data map[string]interface{}) *ValidationResult {
+	return model.validateData(data, CreateMode)
+}
+
+// ValidateMapInterfaceMode normalizes the map interface values before validating
+// them using the validation mode provided.
+func (model *Model) ValidateMapInterfaceMode(data map[string]interface{}, mode ValidationMode) *ValidationResult {
+	return model.validateData(data, mode)
+}
+
+// ValidateValues validates form data expressed as url.Values, e.g. from http.Request.Form
+// or a parsed query string. All required elements must be present (i.e. CreateMode).
+// Fields with repeated values are only accepted for multiple elements. A trailing "[]"
+// on a field name (e.g. "keywords[]") is ignored.
+func (model *Model) ValidateValues(values url.Values) bool {
+	return model.ValidateValuesMode(values, CreateMode).OK()
+}
+
+// ValidateValuesMode validates form data expressed as url.Values using the validation
+// mode provided.
+func (model *Model) ValidateValuesMode(values url.Values, mode ValidationMode) *ValidationResult {
+	return model.validateData(model.valuesToData(values), mode)
+}
+
+// valuesToData converts url.Values into the map used to validate data. Multiple elements
+// are given a list of values, other elements are given a string unless more than
+// one value was submitted.
+func (model *Model) valuesToData(values url.Values) map[string]interface{} {
+	data := map[string]interface{}{}
+	for k, vals := range values {
+		id := k
+		if strings.HasSuffix(k, "[]") && model.HasElement(strings.TrimSuffix(k, "[]")) {
+			id = strings.TrimSuffix(k, "[]")
+		}
+		if prev, ok := data[id]; ok {
+			vals = append(prev.([]string), vals...)
+		}
+		data[id] = append([]string{}, vals...)
+	}
+	for k, v := range data {
+		vals := v.([]string)
+		if elem, ok := model.GetElementById(k); ok && elem.IsMultiple() {
+			continue
+		}
+		if len(vals) == 1 {
+			data[k] = vals[0]
+		}
+	}
+	return data
+}
+
+// isExpected checks if an element must be present in the data for a given
+// validation mode.
+func isExpected(elem *Element, mode ValidationMode) bool {
+	switch mode {
+	case PartialMode:
+		return false
+	case IgnoreGeneratedMode:
+		if elem.Generator != "" {
+			return false
+		}
+	}
+	return elem.IsRequired()
+}
+
+// validateData checks each element of the model against the data provided.
+func (model *Model) validateData(data map[string]interface{}, mode ValidationMode) *ValidationResult {
+	result := new(ValidationResult)
+	if model == nil {
+		if Debug {
+			log.Printf("model is nil, can't validate")
+		}
+		result.Add(nil, "", CodeInvalid, "model is nil, can't validate")
+		return result
+	}
+	model.validateObject(model, "", data, mode, result)
+	return result
+}
+
+// validateObject checks the data against the elements of schema. The schema is either
+// the model itself or one of its sub-models. Failures are recorded in result
+// using the JSON pointer path to the value.
+func (model *Model) validateObject(schema *Model, path string, data map[string]interface{}, mode ValidationMode, result *ValidationResult) {
+	for _, elem := range schema.Elements {
+		if elem.Id == "" {
+			continue
+		}
+		v, ok := data[elem.Id]
+		if isEmptyValue(v) && !isShown(elem, path, data, mode, result) {
+			// A hidden element isn't required
+			continue
+		}
+		if !ok {
+			if isExpected(elem, mode) {
+				if Debug {
+					log.Printf("DEBUG missing elem.Id %q", elem.Id)
+				}
+				result.AddPath(jsonPointer(path, elem.Id), elem, "", CodeMissing, "value is missing")
+			} else if mode != PartialMode && isRequiredIf(elem, path, data, mode, result) {
+				result.AddPath(jsonPointer(path, elem.Id), elem, "", CodeMissing, fmt.Sprintf("value is required when %s", elem.RequiredIf))
+			}
+			continue
+		}
+		if isEmptyValue(v) && !elem.IsRequired() && isRequiredIf(elem, path, data, mode, result) {
+			result.AddPath(jsonPointer(path, elem.Id), elem, "", CodeMissing, fmt.Sprintf("value is required when %s", elem.RequiredIf))
+			continue
+		}
+		model.validateValue(elem, jsonPointer(path, elem.Id), v, mode, result)
+	}
+	// Report any data that doesn't map to an element in a stable order.
+	keys := []string{}
+	for k := range data {
+		if !schema.HasElement(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result.Errors = append(result.Errors, &ValidationError{
+			ElementId: k,
+			Value:     stringifyValue(data[k]),
+			Code:      CodeUnexpectedField,
+			Message:   fmt.Sprintf("%q is not an element of %s", k, schema.Id),
+			Path:      jsonPointer(path, k),
+		})
+	}
+	// Cross field rules are evaluated after the elements have been checked.
+	validateRules(schema, path, data, mode, result)
+}
+
+// validateValue checks a value against the element. Arrays are checked item by
+// item, objects are checked against the sub-model named by the element.
+func (model *Model) validateValue(elem *Element, path string, v interface{}, mode ValidationMode, result *ValidationResult) {
+	switch items := v.(type) {
+	case []interface{}:
+		if !checkCardinality(elem, path, len(items), result) {
+			return
+		}
+		for i, item := range items {
+			model.validateValue(elem, jsonPointer(path, strconv.Itoa(i)), item, mode, result)
+		}
+		return
+	case []string:
+		if !checkCardinality(elem, path, len(items), result) {
+			return
+		}
+		for i, item := range items {
+			model.validateValue(elem, jsonPointer(path, strconv.Itoa(i)), item, mode, result)
+		}
+		return
+	case map[string]interface{}:
+		if objectValue := model.objectValueFor(elem.Type); objectValue != nil && elem.Model == "" {
+			// NOTE: a type can accept its values as JSON objects, e.g. a geo_point
+			if val, ok := objectValue(items); ok {
+				model.validateValue(elem, path, val, mode, result)
+				return
+			}
+		}
+		if elem.Model == "" {
+			result.AddPath(path, elem, stringifyValue(v), CodeInvalid, "unexpected object value, element does not reference a model")
+			return
+		}
+		subModel, ok := model.GetModel(elem.Model)
+		if !ok {
+			result.AddPath(path, elem, "", CodeNoValidator, fmt.Sprintf("model %q is not defined", elem.Model))
+			return
+		}
+		model.validateObject(subModel, path, items, mode, result)
+		return
+	}
+	val := stringifyValue(v)
+	if elem.Model != "" {
+		result.AddPath(path, elem, val, CodeInvalid, fmt.Sprintf("expected an object described by %q", elem.Model))
+		return
+	}
+	validator := model.validatorFor(elem.Type)
+	if validator == nil {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, value %q, missing validator", elem.Id, val)
+		}
+		result.AddPath(path, elem, val, CodeNoValidator, fmt.Sprintf("no validator defined for type %q", elem.Type))
+		return
+	}
+	if code, msg := checkConstraints(elem, val); code != "" {
+		if Debug {
+			log.Printf("DEBUG failed constraint elem.Id %q, elem.Type %q, value %q, %s", elem.Id, elem.Type, val, msg)
+		}
+		result.AddPath(path, elem, val, code, msg)
+		return
+	}
+	if val == "" {
+		// NOTE: like the browser an empty value of an optional element isn't checked by its type
+		return
+	}
+	if !validator(elem, val) {
+		if Debug {
+			log.Printf("DEBUG failed to validate elem.Id %q, elem.Type %q, value %q", elem.Id, elem.Type, val)
+		}
+		code, msg := describeFailure(elem, val)
+		result.AddPath(path, elem, val, code, msg)
+	}
+}
+
+// isEmptyValue checks if a submitted value is missing or empty.
+func isEmptyValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []interface{}:
+		return len(val) == 0
+	case []string:
+		return len(val) == 0
+	}
+	return false
+}
+
+// isShown evaluates an element's show_if condition. An element without a
+// condition, or whose condition can't be decided, is shown.
+func isShown(elem *Element, path string, data map[string]interface{}, mode ValidationMode, result *ValidationResult) bool {
+	if elem.ShowIf == "" {
+		return true
+	}
+	show, decided, err := evalCondition(elem.ShowIf, data, mode)
+	if err != nil {
+		result.AddPath(jsonPointer(path, elem.Id), elem, elem.ShowIf, CodeInvalidRule, fmt.Sprintf("show_if can't be evaluated, %s", err))
+		return true
+	}
+	return show || !decided
+}
+
+// isRequiredIf evaluates an element's required_if condition.
+func isRequiredIf(elem *Element, path string, data map[string]interface{}, mode ValidationMode, result *ValidationResult) bool {
+	if elem.RequiredIf == "" {
+		return false
+	}
+	required, decided, err := evalCondition(elem.RequiredIf, data, mode)
+	if err != nil {
+		result.AddPath(jsonPointer(path, elem.Id), elem, elem.RequiredIf, CodeInvalidRule, fmt.Sprintf("required_if can't be evaluated, %s", err))
+		return false
+	}
+	return required && decided
+}
+
+// checkCardinality checks the number of values submitted for an element. A list
+// of values is only accepted by a multiple element (a list holding a single value is
+// treated as that value). It returns false if a failure was recorded.
+func checkCardinality(elem *Element, path string, count int, result *ValidationResult) bool {
+	if count == 0 && elem.IsRequired() {
+		result.AddPath(path, elem, "", CodeMissing, "a value is required")
+		return false
+	}
+	if !elem.IsMultiple() {
+		if count > 1 {
+			result.AddPath(path, elem, "", CodeTooManyValues, "only one value is allowed")
+			return false
+		}
+		return true
+	}
+	if elem.MinItems > 0 && count < elem.MinItems {
+		result.AddPath(path, elem, "", CodeTooFewValues, fmt.Sprintf("at least %d values are required", elem.MinItems))
+		return false
+	}
+	if elem.MaxItems > 0 && count > elem.MaxItems {
+		result.AddPath(path, elem, "", CodeTooManyValues, fmt.Sprintf("at most %d values are allowed", elem.MaxItems))
+		return false
+	}
+	return true
+}
+
+// stringifyValue converts a value from a map[string]interface{} into the
+// string expected by a ValidateFunc.
+func stringifyValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v.(string)
+	case int:
+		return fmt.Sprintf("%d", v)
+	case int64:
+		return strconv.FormatInt(v.(int64), 10)
+	case float64:
+		// NOTE: use the shortest representation to avoid losing precision
+		return strconv.FormatFloat(v.(float64), 'f', -1, 64)
+	case json.Number:
+		return fmt.Sprintf("%s", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// describeFailure works out the failure code and message for a value
+// rejected by an element's validator.
+func describeFailure(elem *Element, val string) (string, string) {
+	if pattern := getPattern(elem); pattern != "" {
+		if re, err := compilePattern(pattern); err == nil && !re.MatchString(val) {
+			return CodePatternMismatch, fmt.Sprintf("value does not match pattern %q", pattern)
+		}
+	}
+	if len(elem.Options) > 0 && !elem.HasOption(val) {
+		return CodeInvalid, fmt.Sprintf("value is not one of the options %s", strings.Join(elem.OptionValues(), ", "))
+	}
+	if checkBounds, ok := boundsCheckers[elem.Type]; ok {
+		if err := checkBounds(elem, val); err != nil {
+			if bErr, ok := err.(*boundsError); ok {
+				return bErr.code, bErr.msg
+			}
+			// NOTE: parse errors describe the parser not the value so they're kept out of the message
+			if Debug {
+				log.Printf("DEBUG elem.Id %q, elem.Type %q, value %q, %s", elem.Id, elem.Type, val, err)
+			}
+		}
+	}
+	return CodeInvalid, fmt.Sprintf("not a valid %s value", elem.Type)
+}
@@ -0,0 +1,415 @@
+// validation_test.go is part of the Go models package.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2024, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided
+// that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and 
+//    the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions
+//    and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or
+//    promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, 
+// INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY,
+// WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE
+// USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package models
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+// TestValidateReport checks the failures reported when validating form data.
+func TestValidateReport(t *testing.T) {
+	src := []byte(`id: test_report
+description: This is a test of the validation report
+elements:
+  - id: pid
+    type: text
+    attributes:
+      required: true
+    is_primary_id: true
+  - id: orcid
+    type: text
+    pattern: "[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9A-Z]"
+  - id: score
+    type: range
+    attributes:
+      min: "0"
+      max: "10"
+  - id: color
+    type: unknown_type
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+
+	formData := map[string]string{
+		"orcid": "not-an-orcid",
+		"score": "11",
+		"color": "red",
+		"extra": "value",
+	}
+	result := model.ValidateReport(formData)
+	if result.OK() {
+		t.Errorf("expected validation to fail for %+v", formData)
+		t.FailNow()
+	}
+	expected := map[string]string{
+		"pid":   CodeMissing,
+		"orcid": CodePatternMismatch,
+		"score": CodeOutOfRange,
+		"color": CodeNoValidator,
+		"extra": CodeUnexpectedField,
+	}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d, %s", len(expected), len(result.Errors), result)
+	}
+	for _, vErr := range result.Errors {
+		if code, ok := expected[vErr.ElementId]; !ok {
+			t.Errorf("unexpected error %+v", vErr)
+		} else if code != vErr.Code {
+			t.Errorf("expected %q for %q, got %q", code, vErr.ElementId, vErr.Code)
+		}
+	}
+	if model.Validate(formData) {
+		t.Errorf("expected Validate to return false")
+	}
+
+	// Make sure the individual failures can be recovered from the error.
+	err := result.Err()
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Errorf("expected errors.As to find a *ValidationError in %s", err)
+	}
+
+	formData = map[string]string{
+		"pid":   "jane-doe",
+		"orcid": "0000-0003-0900-6903",
+		"score": "5",
+	}
+	model.Define("unknown_type", GenerateText, ValidateText)
+	formData["color"] = "red"
+	if result := model.ValidateReport(formData); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", formData, result)
+	}
+	if err := model.ValidateReport(formData).Err(); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
+
+// TestValidationModes checks required elements are enforced by validation mode.
+func TestValidationModes(t *testing.T) {
+	src := []byte(`id: test_modes
+description: This is a test of validation modes
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+    generator: uuid
+  - id: title
+    type: text
+    attributes:
+      required: true
+  - id: note
+    type: textarea
+  - id: draft
+    type: text
+    attributes:
+      required: "false"
+  - id: updated
+    type: datetime-local
+    attributes:
+      required: true
+    generator: current_timestamp
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+
+	formData := map[string]string{
+		"title": "A title",
+	}
+	result := model.ValidateMode(formData, CreateMode)
+	if len(result.Errors) != 2 {
+		t.Errorf("expected id and updated to be missing, got %s", result)
+	}
+	if result := model.ValidateMode(formData, IgnoreGeneratedMode); !result.OK() {
+		t.Errorf("expected generated elements to be ignored, got %s", result)
+	}
+	if result := model.ValidateMode(map[string]string{}, IgnoreGeneratedMode); result.OK() {
+		t.Errorf("expected title to be required")
+	}
+	formData = map[string]string{
+		"note": "Just a note",
+	}
+	if result := model.ValidateMode(formData, PartialMode); !result.OK() {
+		t.Errorf("expected partial data to validate, got %s", result)
+	}
+	formData["unknown"] = "value"
+	if result := model.ValidateMode(formData, PartialMode); result.OK() {
+		t.Errorf("expected unexpected field to fail partial validation")
+	}
+}
+
+// TestValidateNested checks arrays and objects are validated against the model
+func TestValidateNested(t *testing.T) {
+	src := []byte(`id: test_nested
+description: This is a test of validating nested values
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: creators
+    type: text
+    multiple: true
+    model: creator
+  - id: subjects
+    type: text
+    multiple: true
+    pattern: "^[a-z]+$"
+models:
+  - id: creator
+    elements:
+      - id: family
+        type: text
+        attributes:
+          required: true
+      - id: orcid
+        type: orcid
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	data := map[string]interface{}{
+		"id": "one",
+		"creators": []interface{}{
+			map[string]interface{}{
+				"family": "Doe",
+				"orcid":  "0000-0003-0900-6903",
+			},
+			map[string]interface{}{
+				"family": "Jetson",
+			},
+		},
+		"subjects": []interface{}{"astronomy", "physics"},
+	}
+	if result := model.ValidateMapInterfaceReport(data); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", data, result)
+	}
+	data = map[string]interface{}{
+		"id": "two",
+		"creators": []interface{}{
+			map[string]interface{}{
+				"family": "Doe",
+			},
+			map[string]interface{}{
+				"orcid":    "2345-5432-1234-4326",
+				"nickname": "Jane",
+			},
+			"Jetson, George",
+		},
+		"subjects": []interface{}{"astronomy", "Physics 101"},
+	}
+	result := model.ValidateMapInterfaceReport(data)
+	expected := map[string]string{
+		"/creators/1/family":   CodeMissing,
+		"/creators/1/orcid":    CodeInvalid,
+		"/creators/1/nickname": CodeUnexpectedField,
+		"/creators/2":          CodeInvalid,
+		"/subjects/1":          CodePatternMismatch,
+	}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d, %s", len(expected), len(result.Errors), result)
+	}
+	for _, vErr := range result.Errors {
+		if code, ok := expected[vErr.Path]; !ok || code != vErr.Code {
+			t.Errorf("unexpected error %+v", vErr)
+		}
+	}
+}
+
+// TestConstraints checks required, minlength, maxlength and pattern are applied to every type.
+func TestConstraints(t *testing.T) {
+	src := []byte(`id: test_constraints
+description: This is a test of the shared constraint layer
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: title
+    type: text
+    attributes:
+      required: true
+      minlength: "3"
+      maxlength: "10"
+  - id: code
+    type: call_number
+    pattern: "[A-Z]{2}[0-9]+"
+  - id: email
+    type: email
+    attributes:
+      required: true
+  - id: score
+    type: range
+    attributes:
+      min: "0"
+      max: "10"
+  - id: agree
+    type: checkbox
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	// Custom types get the same constraints as the defaults
+	model.Define("call_number", GenerateText, func(elem *Element, val string) bool {
+		return true
+	})
+	testData := []struct {
+		Data     map[string]string
+		Path     string
+		Expected string
+	}{
+		{map[string]string{"id": "one", "title": "Title", "code": "QB460", "email": "jane@example.edu"}, "", ""},
+		{map[string]string{"id": "", "title": "Title", "code": "QB460", "email": "jane@example.edu"}, "/id", CodeMissing},
+		{map[string]string{"id": "one", "title": "", "code": "QB460", "email": "jane@example.edu"}, "/title", CodeMissing},
+		{map[string]string{"id": "one", "title": "Ti", "code": "QB460", "email": "jane@example.edu"}, "/title", CodeTooShort},
+		{map[string]string{"id": "one", "title": "A much longer title", "code": "QB460", "email": "jane@example.edu"}, "/title", CodeTooLong},
+		{map[string]string{"id": "one", "title": "Title", "code": "XQB460", "email": "jane@example.edu"}, "/code", CodePatternMismatch},
+		{map[string]string{"id": "one", "title": "Title", "code": "", "email": "jane@example.edu"}, "", ""},
+		{map[string]string{"id": "one", "title": "Title", "code": "QB460", "email": ""}, "/email", CodeMissing},
+		// A blank optional range and an unchecked optional checkbox aren't checked by their type
+		{map[string]string{"id": "one", "title": "Title", "code": "QB460", "email": "jane@example.edu", "score": "", "agree": ""}, "", ""},
+		{map[string]string{"id": "one", "title": "Title", "code": "QB460", "email": "jane@example.edu", "score": "11", "agree": ""}, "/score", CodeOutOfRange},
+	}
+	for i, td := range testData {
+		result := model.ValidateReport(td.Data)
+		if td.Expected == "" {
+			if !result.OK() {
+				t.Errorf("(%d) expected %+v to validate, got %s", i, td.Data, result)
+			}
+			continue
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Path != td.Path || result.Errors[0].Code != td.Expected {
+			t.Errorf("(%d) expected %s %q, got %s", i, td.Path, td.Expected, result)
+		}
+	}
+}
+
+// TestValidateValues tests multi-valued fields submitted as url.Values.
+func TestValidateValues(t *testing.T) {
+	src := []byte(`id: test_values
+elements:
+  - id: id
+    type: text
+    is_primary_id: true
+  - id: title
+    type: text
+  - id: topics
+    type: checkbox
+    multiple: true
+    min_items: 1
+    max_items: 2
+    options:
+      - astro: Astronomy
+      - bio: Biology
+      - chem: Chemistry
+  - id: keywords
+    type: text
+    multiple: true
+  - id: formats
+    type: checkbox
+    options:
+      - pdf: PDF
+      - epub: EPUB
+`)
+	model := new(Model)
+	if err := yaml.Unmarshal(src, model); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	SetDefaultTypes(model)
+	values := url.Values{}
+	values.Set("id", "one")
+	values.Set("title", "Stars")
+	values.Add("topics", "astro")
+	values.Add("topics", "chem")
+	values.Add("keywords[]", "galaxy")
+	values.Add("keywords[]", "nebula")
+	if result := model.ValidateValuesMode(values, CreateMode); !result.OK() {
+		t.Errorf("expected %+v to validate, got %s", values, result)
+	}
+	// A single value is accepted by a multiple element
+	values.Set("keywords[]", "galaxy")
+	if !model.ValidateValues(values) {
+		t.Errorf("expected a single keyword to validate")
+	}
+	values.Add("title", "Planets")
+	values.Add("topics", "bio")
+	result := model.ValidateValuesMode(values, CreateMode)
+	expected := map[string]string{
+		"/title":  CodeTooManyValues,
+		"/topics": CodeTooManyValues,
+	}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d, %s", len(expected), len(result.Errors), result)
+	}
+	for _, e := range result.Errors {
+		if code, ok := expected[e.Path]; !ok || code != e.Code {
+			t.Errorf("unexpected error %s (%s)", e, e.Code)
+		}
+	}
+	values.Set("title", "Stars")
+	values.Del("topics")
+	values.Set("topics", "geology")
+	result = model.ValidateValuesMode(values, CreateMode)
+	if len(result.Errors) != 1 || result.Errors[0].Path != "/topics/0" {
+		t.Errorf("expected /topics/0 to fail, got %s", result)
+	}
+	values.Del("topics")
+	result = model.ValidateValuesMode(values, CreateMode)
+	if len(result.Errors) != 1 || result.Errors[0].Code != CodeMissing {
+		t.Errorf("expected topics to be missing, got %s", result)
+	}
+	if result = model.ValidateValuesMode(values, PartialMode); !result.OK() {
+		t.Errorf("expected a partial update without topics to validate, got %s", result)
+	}
+	// A checkbox with options is rendered as a group so it holds a list of values
+	elem, _ := model.GetElementById("formats")
+	if !elem.IsMultiple() {
+		t.Errorf("expected a checkbox with options to be multiple")
+	}
+	values.Add("formats", "pdf")
+	values.Add("formats", "epub")
+	if result = model.ValidateValuesMode(values, PartialMode); !result.OK() {
+		t.Errorf("expected more than one checked format to validate, got %s", result)
+	}
+}